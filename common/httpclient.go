@@ -0,0 +1,183 @@
+package common
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 数据商共用的http客户端
+// 相同代理的请求共用连接池, 保持长连接并自动处理gzip
+// 不做重试, 重试由调用方结合限流处理, 按域名统计请求耗时与状态码
+
+const (
+	defaultHttpTimeout      = 3 * time.Second
+	httpMaxIdleConns        = 100
+	httpMaxIdleConnsPerHost = 10
+	httpIdleConnTimeout     = 90 * time.Second
+)
+
+type HttpResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+type HttpClient struct {
+	client    *http.Client
+	timeout   time.Duration // 单次请求超时
+	userAgent string
+}
+
+var (
+	httpTransports    = make(map[string]*http.Transport) // 代理地址 -> 连接池
+	httpTransportLock sync.Mutex
+)
+
+// proxy为空时直连, 支持http、https与socks5代理
+func NewHttpClient(proxy string, timeout time.Duration, userAgent string) (*HttpClient, error) {
+	transport, err := getTransport(proxy)
+	if err != nil {
+		return nil, err
+	}
+	if timeout <= 0 {
+		timeout = defaultHttpTimeout
+	}
+	return &HttpClient{
+		client:    &http.Client{Transport: transport},
+		timeout:   timeout,
+		userAgent: userAgent,
+	}, nil
+}
+
+func getTransport(proxy string) (*http.Transport, error) {
+	httpTransportLock.Lock()
+	defer httpTransportLock.Unlock()
+
+	if transport, ok := httpTransports[proxy]; ok {
+		return transport, nil
+	}
+
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          httpMaxIdleConns,
+		MaxIdleConnsPerHost:   httpMaxIdleConnsPerHost,
+		IdleConnTimeout:       httpIdleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+	if proxy != "" {
+		u, err := url.Parse(proxy)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(u)
+	}
+	httpTransports[proxy] = transport
+	return transport, nil
+}
+
+// GET请求
+func (c *HttpClient) Get(rawUrl string) (*HttpResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	start := time.Now()
+	resp, err := c.client.Do(req)
+	if err != nil {
+		recordHttpStat(req.URL.Host, 0, time.Since(start))
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	recordHttpStat(req.URL.Host, resp.StatusCode, time.Since(start))
+	if err != nil {
+		return nil, err
+	}
+	return &HttpResponse{StatusCode: resp.StatusCode, Header: resp.Header, Body: body}, nil
+}
+
+// -----------------------------------------------------------------------------
+
+type HttpHostStat struct {
+	Host       string           `json:"host"`
+	Requests   int64            `json:"requests"`   // 总请求次数
+	Errors     int64            `json:"errors"`     // 网络错误次数
+	Status     map[string]int64 `json:"status"`     // 状态码 -> 次数
+	Latency    int64            `json:"latency"`    // 最近一次请求耗时 毫秒
+	AvgLatency int64            `json:"avgLatency"` // 指数加权平均请求耗时 毫秒, 最近一次占1/10
+	MaxLatency int64            `json:"maxLatency"` // 最大请求耗时 毫秒
+}
+
+var (
+	httpStats    = make(map[string]*HttpHostStat)
+	httpStatLock sync.RWMutex
+)
+
+// status为0表示网络错误
+func recordHttpStat(host string, status int, latency time.Duration) {
+	httpStatLock.Lock()
+	defer httpStatLock.Unlock()
+
+	stat, ok := httpStats[host]
+	if !ok {
+		stat = &HttpHostStat{Host: host, Status: make(map[string]int64)}
+		httpStats[host] = stat
+	}
+
+	stat.Requests++
+	if status == 0 {
+		stat.Errors++
+	} else {
+		stat.Status[strconv.Itoa(status)]++
+	}
+	stat.Latency = int64(latency / time.Millisecond)
+	// 平均耗时按指数加权计算, 突出最近的请求
+	if stat.Requests == 1 {
+		stat.AvgLatency = stat.Latency
+	} else {
+		stat.AvgLatency = (stat.AvgLatency*9 + stat.Latency) / 10
+	}
+	if stat.Latency > stat.MaxLatency {
+		stat.MaxLatency = stat.Latency
+	}
+}
+
+// 各域名的请求统计, 按域名排序
+func HttpStats() []HttpHostStat {
+	httpStatLock.RLock()
+	defer httpStatLock.RUnlock()
+
+	list := make([]HttpHostStat, 0)
+	for _, stat := range httpStats {
+		item := *stat
+		item.Status = make(map[string]int64)
+		for k, v := range stat.Status {
+			item.Status[k] = v
+		}
+		list = append(list, item)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Host < list[j].Host
+	})
+	return list
+}
@@ -0,0 +1,69 @@
+package common
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestHttpClient(t *testing.T) {
+	// 第一次返回503, 客户端不重试
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("User-Agent") != "kline-test" {
+			t.Errorf("unexpected user agent: %s", r.Header.Get("User-Agent"))
+		}
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	client, err := NewHttpClient("", time.Second, "kline-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusServiceUnavailable || requests != 1 {
+		t.Fatalf("unexpected response: %d after %d requests", resp.StatusCode, requests)
+	}
+	resp, err = client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || string(resp.Body) != "ok" || requests != 2 {
+		t.Fatalf("unexpected response: %d %s after %d requests", resp.StatusCode, resp.Body, requests)
+	}
+
+	u, _ := url.Parse(server.URL)
+	for _, stat := range HttpStats() {
+		if stat.Host != u.Host {
+			continue
+		}
+		if stat.Requests != 2 || stat.Status["503"] != 1 || stat.Status["200"] != 1 {
+			t.Fatalf("unexpected stat: %+v", stat)
+		}
+		return
+	}
+	t.Fatal("no stat for host " + u.Host)
+}
+
+func TestHttpClientSharedTransport(t *testing.T) {
+	a, _ := NewHttpClient("socks5://127.0.0.1:1088", 0, "")
+	b, _ := NewHttpClient("socks5://127.0.0.1:1088", 0, "")
+	if a.client.Transport != b.client.Transport {
+		t.Fatal("clients with same proxy should share transport")
+	}
+	if _, err := NewHttpClient("://bad", 0, ""); err == nil {
+		t.Fatal("bad proxy should return error")
+	}
+}
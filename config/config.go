@@ -25,6 +25,8 @@ var (
 		constant.CoinTypeETHUSDT,
 		constant.CoinTypeBTCUSDT,
 		constant.CoinTypeRUCNY,
+		constant.CoinTypeCUCNY,
+		constant.CoinTypeMCNY,
 	}
 	TimeScaleMap = map[string]int{
 		"1":  60,
@@ -59,8 +61,19 @@ func InitConfig() {
 		panic("configuration file " + fileName + " is privilege mode is not right")
 	}
 
+	if err := LoadConfig(fileName); err != nil {
+		panic(err.Error())
+	}
+}
+
+// 加载指定的配置文件, 替换已加载的全部配置, 仅在启动时或测试中调用
+func LoadConfig(fileName string) error {
+	if _, err := os.Stat(fileName); err != nil {
+		return err
+	}
 	conf := goini.SetConfig(fileName)
 	configData = conf.ReadList()
+	return nil
 }
 
 func GetConfig(section string, key string) string {
@@ -7,6 +7,8 @@ const (
 	CoinTypeBTCCNY  = "BTC/CNY"
 	CoinTypeRUUSDT  = "RU/USDT"
 	CoinTypeRUCNY   = "RU/CNY"
+	CoinTypeCUCNY   = "CU/CNY" // 沪铜期货
+	CoinTypeMCNY    = "M/CNY"  // 豆粕期货
 )
 
 const (
@@ -52,5 +54,7 @@ const (
 
 // mq事件
 const (
-	MqEventTypeTick = "tick_"
+	MqEventTypeTick   = "tick_"
+	MqEventTypeAlert  = "alert_"  // 告警事件, 如价格跳变
+	MqEventTypeStatus = "status_" // 行情质量状态事件
 )
@@ -3,7 +3,6 @@ module bitcoin-kline
 go 1.13
 
 require (
-	github.com/elazarl/goproxy v0.0.0-20191011121108-aa519ddbe484 // indirect
 	github.com/fastly/go-utils v0.0.0-20180712184237-d95a45783239 // indirect
 	github.com/gin-gonic/gin v1.5.0
 	github.com/go-redis/redis v6.15.6+incompatible
@@ -16,7 +15,6 @@ require (
 	github.com/kr/pretty v0.1.0 // indirect
 	github.com/lestrrat-go/file-rotatelogs v2.2.0+incompatible
 	github.com/lestrrat-go/strftime v1.0.0 // indirect
-	github.com/parnurzeal/gorequest v0.2.16
 	github.com/pkg/errors v0.8.1
 	github.com/rifflock/lfshook v0.0.0-20180920164130-b9218ef580f5
 	github.com/shopspring/decimal v0.0.0-20191130220710-360f2bc03045
@@ -26,6 +24,5 @@ require (
 	github.com/streadway/amqp v0.0.0-20190827072141-edfb9018d271
 	github.com/tebeka/strftime v0.1.3 // indirect
 	github.com/widuu/goini v0.0.0-20180603013956-56a38bd2e09b
-	golang.org/x/net v0.0.0-20191209160850-c0dbc17a3553 // indirect
-	moul.io/http2curl v1.0.0 // indirect
+	golang.org/x/net v0.0.0-20191209160850-c0dbc17a3553
 )
@@ -1,6 +1,7 @@
 package hub
 
 import (
+	"bitcoin-kline/hub/session"
 	"bitcoin-kline/hub/worker"
 )
 
@@ -20,7 +21,14 @@ func NewHub() *Hub {
 	}
 }
 
+func (h *Hub) ProviderWorker() *worker.ProviderWorker {
+	return h.providerW
+}
+
 func (h *Hub) Start() error {
+	if err := session.InitSession(); err != nil {
+		return err
+	}
 	worker.InitProviderWorker()
 	if err := h.providerW.Start(); err != nil {
 		return err
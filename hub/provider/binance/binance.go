@@ -1,41 +1,34 @@
 package binance
 
 import (
-	"bitcoin-kline/config"
 	"bitcoin-kline/constant"
-	"bitcoin-kline/logger"
-	"bitcoin-kline/model"
+	"bitcoin-kline/hub/provider"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
-	"sync"
-	"time"
-
-	"github.com/parnurzeal/gorequest"
+	"net/url"
 )
 
-// 官网：https://www.gate.io/
-// API文档：https://www.gate.io/api2#ticker
+// 官网：https://www.binance.com/
+// API文档：https://github.com/binance-exchange/binance-official-api-docs/blob/master/rest-api.md
 // 限频：
-// API域名：https://data.gateio.life/
-// 行情接口：https://www.gate.io/api2#ticker
-
-type Provider struct {
-	readChan map[string]chan *model.Kline
+// API域名：https://api.binance.com/
+// 行情接口：https://github.com/binance-exchange/binance-official-api-docs/blob/master/rest-api.md#24hr-ticker-price-change-statistics
 
-	//currentKline map[string]chan *model.Kline
-
-	breakMainLogic chan bool // 结束命令管道
-	sync.WaitGroup
-}
+const baseUrl = "https://api.binance.com"
 
 type Ticker struct {
 	Last string `json:"lastPrice"` // 本阶段最新价
 	High string `json:"highPrice"` // 本阶段最高价
 	Low  string `json:"lowPrice"`  // 本阶段最低价
 	Open string `json:"openPrice"` // 本阶段开盘价
-	Vol  string `json:"volume"`    // 以报价币种计量的交易量
+	Vol  string `json:"volume"`    // 以基础币种计量的交易量
+	Time int64  `json:"closeTime"` // 统计结束时间 毫秒
+
+	Bid     string `json:"bidPrice"` // 买一价
+	Ask     string `json:"askPrice"` // 卖一价
+	BidSize string `json:"bidQty"`   // 买一量
+	AskSize string `json:"askQty"`   // 卖一量
 }
 
 type ApiResponse struct {
@@ -51,109 +44,35 @@ var (
 	}
 )
 
-func NewProvider() *Provider {
-	p := &Provider{
-		readChan:       make(map[string]chan *model.Kline),
-		breakMainLogic: make(chan bool),
-	}
-
-	for _, coinTyp := range config.SupportCoinTypes {
-		p.readChan[coinTyp] = make(chan *model.Kline)
-	}
-
-	return p
+func init() {
+	provider.Register(constant.ProviderBinance, func() provider.Provider { return NewProvider() })
+	provider.RegisterStream(constant.ProviderBinance, func() provider.Provider { return NewStreamProvider() })
+	provider.RegisterTrade(constant.ProviderBinance, func() provider.Provider { return NewTradeProvider() })
 }
 
-func (p *Provider) ReadChan(coinType string) <-chan *model.Kline {
-	return p.readChan[coinType]
+func NewProvider() *provider.PollProvider {
+	return provider.NewPollProvider(provider.PollConfig{
+		Name:        constant.ProviderBinance,
+		Origin:      constant.ProviderBinanceOriginType,
+		VolumeUnit:  provider.VolumeBase,
+		CoinMap:     coinMap,
+		BaseUrl:     baseUrl,
+		RateLimit:   20,
+		Url:         tickerUrl,
+		Decode:      decodeTicker,
+		BatchUrl:    tickersUrl,
+		BatchDecode: decodeTickers,
+	})
 }
 
-func (p *Provider) StartCollect() {
-	for _, coinType := range config.SupportCoinTypes {
-		if _, ok := coinMap[coinType]; ok {
-			p.Add(1)
-			go func(c string) {
-				defer p.Done()
-				p.loop(c)
-			}(coinType)
-		}
-	}
-}
-
-func (p *Provider) loop(params ...interface{}) {
-	if len(params) <= 0 {
-		return
-	}
-	coinType := params[0].(string)
-	t := time.NewTicker(time.Second)
-	defer t.Stop()
-
-	for {
-		select {
-		case <-t.C:
-
-			ticker, err := p.getTicker(coinType)
-			if err != nil {
-				logger.Error("BinanceProvider_getTicker", coinType, err.Error())
-				break
-			}
-			now := time.Now().Unix()
-			price := ticker.Last
-			vol := ticker.Vol
-
-			item := &model.Kline{
-				CoinType:    coinType,
-				High:        price,
-				Low:         price,
-				Open:        price,
-				Close:       price,
-				CreateTime:  now,
-				UpdateTime:  now,
-				TimeScale:   "1s",
-				Origin:      constant.ProviderBinanceOriginType,
-				OriginPrice: "",
-				Volume:      vol,
-			}
-
-			p.handleKline(coinType, item)
-			break
-		case <-p.breakMainLogic:
-			return
-		}
-	}
-}
-
-func (p *Provider) handleKline(coinType string, kline *model.Kline) {
-	select {
-	case p.readChan[coinType] <- kline:
-
-	case <-time.After(time.Second * constant.ProviderDataExpireTime):
-	case <-p.breakMainLogic:
-	}
-}
-
-func (p *Provider) Stop() {
-	close(p.breakMainLogic)
-	p.Wait()
-}
-
-// request data here
 // 此接口获取ticker信息同时提供最近24小时的交易聚合信息。
-func (p *Provider) getTicker(coinType string) (*Ticker, error) {
-	symbol := coinMap[coinType]
-	request := gorequest.New()
-	if os.Getenv("RUNMODE") == "dev" {
-		request = request.Proxy("socks5://127.0.0.1:1088")
-	}
-
-	url := fmt.Sprintf("https://api.binance.com/api/v3/ticker/24hr?symbol=%s", symbol)
-	_, body, errs := request.Get(url).Timeout(3 * time.Second).End()
-	if len(errs) > 0 {
-		return nil, errs[0]
-	}
+func tickerUrl(base, symbol string) string {
+	return fmt.Sprintf(base+"/api/v3/ticker/24hr?symbol=%s", symbol)
+}
 
+func decodeTicker(body []byte) (*provider.Tick, error) {
 	resp := &ApiResponse{}
-	if err := json.Unmarshal([]byte(body), resp); err != nil {
+	if err := json.Unmarshal(body, resp); err != nil {
 		return nil, err
 	}
 	if resp.Code != 0 {
@@ -165,5 +84,49 @@ func (p *Provider) getTicker(coinType string) (*Ticker, error) {
 		return nil, errors.New("data invalid")
 	}
 
-	return tick, nil
+	return &provider.Tick{
+		Last:    tick.Last,
+		Vol:     tick.Vol,
+		Time:    tick.Time,
+		Bid:     tick.Bid,
+		Ask:     tick.Ask,
+		BidSize: tick.BidSize,
+		AskSize: tick.AskSize,
+	}, nil
+}
+
+// 一次获取多个交易对的24小时行情, 1-20个交易对权重为2
+func tickersUrl(base string, symbols []string) string {
+	data, _ := json.Marshal(symbols)
+	return fmt.Sprintf("%s/api/v3/ticker/24hr?symbols=%s", base, url.QueryEscape(string(data)))
+}
+
+// 成功时返回数组, 失败时返回错误对象
+func decodeTickers(body []byte) ([]*provider.Tick, error) {
+	list := make([]*struct {
+		Symbol string `json:"symbol"`
+		Ticker
+	}, 0)
+	if err := json.Unmarshal(body, &list); err != nil {
+		resp := &ApiResponse{}
+		if json.Unmarshal(body, resp) == nil && resp.Code != 0 {
+			return nil, errors.New(resp.Message)
+		}
+		return nil, err
+	}
+
+	ticks := make([]*provider.Tick, 0)
+	for _, item := range list {
+		ticks = append(ticks, &provider.Tick{
+			Symbol:  item.Symbol,
+			Last:    item.Last,
+			Vol:     item.Vol,
+			Time:    item.Time,
+			Bid:     item.Bid,
+			Ask:     item.Ask,
+			BidSize: item.BidSize,
+			AskSize: item.AskSize,
+		})
+	}
+	return ticks, nil
 }
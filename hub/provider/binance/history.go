@@ -0,0 +1,60 @@
+package binance
+
+import (
+	"bitcoin-kline/constant"
+	"bitcoin-kline/hub/provider"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+func init() {
+	provider.RegisterHistory(provider.HistoryConfig{
+		Name:       constant.ProviderBinance,
+		Origin:     constant.ProviderBinanceOriginType,
+		VolumeUnit: provider.VolumeBase,
+		CoinMap:    coinMap,
+		BaseUrl:    baseUrl,
+		Limit:      1000,
+		Url:        klinesUrl,
+		Decode:     decodeKlines,
+	})
+}
+
+// 1分钟k线, 时间参数为毫秒, 单次最多1000根
+func klinesUrl(base, symbol string, start, end int64) string {
+	return fmt.Sprintf("%s/api/v3/klines?symbol=%s&interval=1m&startTime=%d&endTime=%d&limit=1000",
+		base, symbol, start*1000, end*1000-1)
+}
+
+// [开盘时间, 开盘价, 最高价, 最低价, 收盘价, 成交量, 收盘时间, 成交额, ...]
+func decodeKlines(body []byte) ([]*provider.Candle, error) {
+	list := make([][]interface{}, 0)
+	if err := json.Unmarshal(body, &list); err != nil {
+		resp := &ApiResponse{}
+		if json.Unmarshal(body, resp) == nil && resp.Code != 0 {
+			return nil, errors.New(resp.Message)
+		}
+		return nil, err
+	}
+
+	candles := make([]*provider.Candle, 0)
+	for _, item := range list {
+		if len(item) < 6 {
+			return nil, errors.New("kline data err")
+		}
+		ts, ok := item[0].(float64)
+		if !ok {
+			return nil, errors.New("kline time err")
+		}
+		candles = append(candles, &provider.Candle{
+			Time:  int64(ts) / 1000,
+			Open:  fmt.Sprint(item[1]),
+			High:  fmt.Sprint(item[2]),
+			Low:   fmt.Sprint(item[3]),
+			Close: fmt.Sprint(item[4]),
+			Vol:   fmt.Sprint(item[5]),
+		})
+	}
+	return candles, nil
+}
@@ -0,0 +1,88 @@
+package binance
+
+import (
+	"bitcoin-kline/constant"
+	"bitcoin-kline/hub/provider"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// websocket文档：https://github.com/binance-exchange/binance-official-api-docs/blob/master/web-socket-streams.md
+// 服务端每3分钟发送ping帧, 由websocket库自动回复pong
+
+const wsUrl = "wss://stream.binance.com:9443/ws"
+
+type streamHandler struct{}
+
+// json解析字段名不区分大小写, 推送中仅大小写不同的字段(E、C)需一并声明, 否则会解析到小写字段而报错
+type streamResponse struct {
+	Event     string `json:"e"`
+	EventTime int64  `json:"E"` // 事件时间 毫秒
+	Symbol    string `json:"s"`
+	Last      string `json:"c"` // 最新成交价
+	CloseTime int64  `json:"C"` // 统计结束时间 毫秒
+	Vol       string `json:"v"` // 以基础币种计量的成交量
+	Bid       string `json:"b"` // 买一价
+	BidSize   string `json:"B"` // 买一量
+	Ask       string `json:"a"` // 卖一价
+	AskSize   string `json:"A"` // 卖一量
+	Error     *struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	} `json:"error"`
+}
+
+func NewStreamProvider() *provider.StreamProvider {
+	return provider.NewStreamProvider(provider.StreamConfig{
+		Name:       constant.ProviderBinance,
+		Origin:     constant.ProviderBinanceOriginType,
+		VolumeUnit: provider.VolumeBase,
+		CoinMap:    coinMap,
+		Url:        wsUrl,
+		Handler:    &streamHandler{},
+	})
+}
+
+func (h *streamHandler) Url(base string, symbols []string) string {
+	return base
+}
+
+// 订阅24小时ticker <symbol>@ticker
+func (h *streamHandler) Subscribe(symbols []string) []string {
+	params := make([]string, 0)
+	for _, symbol := range symbols {
+		params = append(params, strings.ToLower(symbol)+"@ticker")
+	}
+	msg, _ := json.Marshal(map[string]interface{}{"method": "SUBSCRIBE", "params": params, "id": 1})
+	return []string{string(msg)}
+}
+
+func (h *streamHandler) Ping() string {
+	return ""
+}
+
+func (h *streamHandler) Decode(msg []byte) ([]*provider.Tick, string, error) {
+	resp := &streamResponse{}
+	if err := json.Unmarshal(msg, resp); err != nil {
+		return nil, "", err
+	}
+	if resp.Error != nil {
+		return nil, "", errors.New(resp.Error.Msg)
+	}
+	if resp.Event != "24hrTicker" {
+		return nil, "", nil
+	}
+
+	tick := &provider.Tick{
+		Symbol:  resp.Symbol,
+		Last:    resp.Last,
+		Vol:     resp.Vol,
+		Time:    resp.EventTime,
+		Bid:     resp.Bid,
+		Ask:     resp.Ask,
+		BidSize: resp.BidSize,
+		AskSize: resp.AskSize,
+	}
+	return []*provider.Tick{tick}, "", nil
+}
@@ -0,0 +1,80 @@
+package binance
+
+import (
+	"bitcoin-kline/hub/provider"
+	"testing"
+)
+
+// 抓取的24小时ticker推送, 含仅大小写不同的字段
+const tickerFrame = `{"e":"24hrTicker","E":1576209600123,"s":"ETHUSDT","p":"1.71000000","P":"1.208","w":"142.61502437","x":"141.50000000","c":"143.21000000","Q":"0.50000000","b":"143.20000000","B":"3.50000000","a":"143.22000000","A":"1.20000000","o":"141.50000000","h":"144.80000000","l":"140.90000000","v":"179000.10000000","q":"25528134.27150000","O":1576123200123,"C":1576209600123,"F":205123456,"L":205203777,"n":80322}`
+
+func TestStreamSubscribe(t *testing.T) {
+	msgs := (&streamHandler{}).Subscribe([]string{"ETHUSDT", "BTCUSDT"})
+	if len(msgs) != 1 || msgs[0] != `{"id":1,"method":"SUBSCRIBE","params":["ethusdt@ticker","btcusdt@ticker"]}` {
+		t.Fatalf("unexpected subscribe: %v", msgs)
+	}
+	msgs = (&tradeHandler{}).Subscribe([]string{"ETHUSDT"})
+	if len(msgs) != 1 || msgs[0] != `{"id":1,"method":"SUBSCRIBE","params":["ethusdt@trade"]}` {
+		t.Fatalf("unexpected trade subscribe: %v", msgs)
+	}
+}
+
+func TestStreamDecode(t *testing.T) {
+	cases := []struct {
+		name  string
+		msg   string
+		ticks []provider.Tick
+		err   bool
+	}{
+		{name: "ticker", msg: tickerFrame, ticks: []provider.Tick{{
+			Symbol: "ETHUSDT", Last: "143.21000000", Vol: "179000.10000000", Time: 1576209600123,
+			Bid: "143.20000000", Ask: "143.22000000", BidSize: "3.50000000", AskSize: "1.20000000",
+		}}},
+		{name: "subscribed", msg: `{"result":null,"id":1}`},
+		{name: "api error", msg: `{"error":{"code":2,"msg":"Invalid request: unknown variant"},"id":1}`, err: true},
+		{name: "truncated", msg: tickerFrame[:len(tickerFrame)/2], err: true},
+		{name: "wrong type", msg: `{"e":"24hrTicker","E":"1576209600123"}`, err: true},
+		{name: "empty", msg: "", err: true},
+	}
+	h := &streamHandler{}
+	for _, c := range cases {
+		ticks, reply, err := h.Decode([]byte(c.msg))
+		if (err != nil) != c.err || reply != "" || len(ticks) != len(c.ticks) {
+			t.Errorf("%s: unexpected result %v %q %v", c.name, ticks, reply, err)
+			continue
+		}
+		for i, tick := range ticks {
+			if *tick != c.ticks[i] {
+				t.Errorf("%s: expect %+v, got %+v", c.name, c.ticks[i], *tick)
+			}
+		}
+	}
+}
+
+func TestTradeDecode(t *testing.T) {
+	cases := []struct {
+		name   string
+		msg    string
+		trades []provider.Trade
+		err    bool
+	}{
+		{name: "trade", msg: `{"e":"trade","E":1576209600456,"s":"ETHUSDT","t":205203778,"p":"143.21000000","q":"0.50000000","b":1103234567,"a":1103234560,"T":1576209600450,"m":true,"M":true}`,
+			trades: []provider.Trade{{Symbol: "ETHUSDT", Price: "143.21000000", Size: "0.50000000", Side: provider.SideSell, Time: 1576209600450}}},
+		{name: "subscribed", msg: `{"result":null,"id":1}`},
+		{name: "api error", msg: `{"error":{"code":2,"msg":"Invalid request"},"id":1}`, err: true},
+		{name: "malformed", msg: `{"e":"trade",`, err: true},
+	}
+	h := &tradeHandler{}
+	for _, c := range cases {
+		trades, reply, err := h.Decode([]byte(c.msg))
+		if (err != nil) != c.err || reply != "" || len(trades) != len(c.trades) {
+			t.Errorf("%s: unexpected result %v %q %v", c.name, trades, reply, err)
+			continue
+		}
+		for i, item := range trades {
+			if *item != c.trades[i] {
+				t.Errorf("%s: expect %+v, got %+v", c.name, c.trades[i], *item)
+			}
+		}
+	}
+}
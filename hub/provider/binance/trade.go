@@ -0,0 +1,84 @@
+package binance
+
+import (
+	"bitcoin-kline/constant"
+	"bitcoin-kline/hub/provider"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// 逐笔成交 <symbol>@trade
+// {"e":"trade","E":123456789,"s":"BNBBTC","t":12345,"p":"0.001","q":"100","b":88,"a":50,"T":123456785,"m":true,"M":true}
+
+type tradeHandler struct{}
+
+// 仅大小写不同的字段(e/E、t/T、m/M)需全部声明, 原因同streamResponse
+type tradeResponse struct {
+	Event     string `json:"e"`
+	EventTime int64  `json:"E"`
+	Symbol    string `json:"s"`
+	TradeId   int64  `json:"t"`
+	Price     string `json:"p"`
+	Qty       string `json:"q"` // 以基础币种计量
+	TradeTime int64  `json:"T"` // 成交时间 毫秒
+	Maker     bool   `json:"m"` // 买方是否为挂单方, 是则为主动卖出
+	Ignore    bool   `json:"M"`
+	Error     *struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	} `json:"error"`
+}
+
+func NewTradeProvider() *provider.StreamProvider {
+	return provider.NewStreamProvider(provider.StreamConfig{
+		Name:    constant.ProviderBinance,
+		Origin:  constant.ProviderBinanceOriginType,
+		CoinMap: coinMap,
+		Url:     wsUrl,
+		Trade:   &tradeHandler{},
+	})
+}
+
+func (h *tradeHandler) Url(base string, symbols []string) string {
+	return base
+}
+
+func (h *tradeHandler) Subscribe(symbols []string) []string {
+	params := make([]string, 0)
+	for _, symbol := range symbols {
+		params = append(params, strings.ToLower(symbol)+"@trade")
+	}
+	msg, _ := json.Marshal(map[string]interface{}{"method": "SUBSCRIBE", "params": params, "id": 1})
+	return []string{string(msg)}
+}
+
+func (h *tradeHandler) Ping() string {
+	return ""
+}
+
+func (h *tradeHandler) Decode(msg []byte) ([]*provider.Trade, string, error) {
+	resp := &tradeResponse{}
+	if err := json.Unmarshal(msg, resp); err != nil {
+		return nil, "", err
+	}
+	if resp.Error != nil {
+		return nil, "", errors.New(resp.Error.Msg)
+	}
+	if resp.Event != "trade" {
+		return nil, "", nil
+	}
+
+	side := provider.SideBuy
+	if resp.Maker {
+		side = provider.SideSell
+	}
+	trade := &provider.Trade{
+		Symbol: resp.Symbol,
+		Price:  resp.Price,
+		Size:   resp.Qty,
+		Side:   side,
+		Time:   resp.TradeTime,
+	}
+	return []*provider.Trade{trade}, "", nil
+}
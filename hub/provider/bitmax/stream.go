@@ -0,0 +1,64 @@
+package bitmax
+
+import (
+	"bitcoin-kline/constant"
+	"bitcoin-kline/hub/provider"
+	"encoding/json"
+	"strings"
+)
+
+// websocket文档：https://github.com/bitmax-exchange/api-doc/blob/master/bitmax-api-doc-v1.2.md#websocket
+// 每个交易对单独建立连接, 地址为 wss://bitmax.io/api/public/ETH-USDT
+
+const wsUrl = "wss://bitmax.io/api/public/"
+
+type streamHandler struct{}
+
+type streamResponse struct {
+	MessageType string `json:"m"`
+	Symbol      string `json:"s"` // ETH/USDT
+	Last        string `json:"c"`
+	Vol         string `json:"v"`
+}
+
+func NewStreamProvider() *provider.StreamProvider {
+	return provider.NewStreamProvider(provider.StreamConfig{
+		Name:       constant.ProviderBitmax,
+		Origin:     constant.ProviderBitmaxOriginType,
+		VolumeUnit: provider.VolumeBase,
+		CoinMap:    coinMap,
+		Url:        wsUrl,
+		Handler:    &streamHandler{},
+		PerSymbol:  true,
+	})
+}
+
+func (h *streamHandler) Url(base string, symbols []string) string {
+	return base + symbols[0]
+}
+
+// 只订阅市场概要, 跳过深度、成交与k线
+func (h *streamHandler) Subscribe(symbols []string) []string {
+	return []string{`{"messageType":"subscribe","marketDepthLevel":0,"recentTradeMaxCount":0,"skipSummary":false,"skipBars":true}`}
+}
+
+func (h *streamHandler) Ping() string {
+	return ""
+}
+
+func (h *streamHandler) Decode(msg []byte) ([]*provider.Tick, string, error) {
+	resp := &streamResponse{}
+	if err := json.Unmarshal(msg, resp); err != nil {
+		return nil, "", err
+	}
+	if resp.MessageType != "summary" {
+		return nil, "", nil
+	}
+
+	tick := &provider.Tick{
+		Symbol: strings.Replace(resp.Symbol, "/", "-", -1),
+		Last:   resp.Last,
+		Vol:    resp.Vol,
+	}
+	return []*provider.Tick{tick}, "", nil
+}
@@ -0,0 +1,46 @@
+package bitmax
+
+import (
+	"bitcoin-kline/hub/provider"
+	"testing"
+)
+
+func TestStreamSubscribe(t *testing.T) {
+	h := &streamHandler{}
+	if url := h.Url("wss://bitmax.io/api/public/", []string{"ETH-USDT"}); url != "wss://bitmax.io/api/public/ETH-USDT" {
+		t.Fatalf("unexpected url: %s", url)
+	}
+	msgs := h.Subscribe([]string{"ETH-USDT"})
+	if len(msgs) != 1 || msgs[0] != `{"messageType":"subscribe","marketDepthLevel":0,"recentTradeMaxCount":0,"skipSummary":false,"skipBars":true}` {
+		t.Fatalf("unexpected subscribe: %v", msgs)
+	}
+}
+
+func TestStreamDecode(t *testing.T) {
+	cases := []struct {
+		name  string
+		msg   string
+		ticks []provider.Tick
+		err   bool
+	}{
+		{name: "summary", msg: `{"m":"summary","s":"ETH/USDT","ba":"ETH","qa":"USDT","i":"1d","t":1576209600000,"o":"141.5","c":"143.21","h":"144.8","l":"140.9","v":"179000.1"}`,
+			ticks: []provider.Tick{{Symbol: "ETH-USDT", Last: "143.21", Vol: "179000.1"}}},
+		{name: "depth", msg: `{"m":"depth","s":"ETH/USDT","asks":[["143.22","1.2"]],"bids":[["143.2","3.5"]]}`},
+		{name: "truncated", msg: `{"m":"summary","s":"ETH/USDT","c":`, err: true},
+		{name: "wrong type", msg: `{"m":"summary","c":143.21}`, err: true},
+		{name: "empty", msg: "", err: true},
+	}
+	h := &streamHandler{}
+	for _, c := range cases {
+		ticks, reply, err := h.Decode([]byte(c.msg))
+		if (err != nil) != c.err || reply != "" || len(ticks) != len(c.ticks) {
+			t.Errorf("%s: unexpected result %v %q %v", c.name, ticks, reply, err)
+			continue
+		}
+		for i, tick := range ticks {
+			if *tick != c.ticks[i] {
+				t.Errorf("%s: expect %+v, got %+v", c.name, c.ticks[i], *tick)
+			}
+		}
+	}
+}
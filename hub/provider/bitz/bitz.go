@@ -1,17 +1,12 @@
 package bitz
 
 import (
-	"bitcoin-kline/config"
 	"bitcoin-kline/constant"
-	"bitcoin-kline/logger"
-	"bitcoin-kline/model"
+	"bitcoin-kline/hub/provider"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"sync"
 	"time"
-
-	"github.com/parnurzeal/gorequest"
 )
 
 // 官网：https://www.bitz.top/ www.bit-z.pro www.bit-z.com www.bitz.com
@@ -19,27 +14,26 @@ import (
 // 限频：
 // 行情接口：https://apidoc.bitz.top/cn/market-quotation-data/Get-ticker-data.html
 
-type Provider struct {
-	readChan map[string]chan *model.Kline
-
-	//currentKline map[string]chan *model.Kline
-
-	breakMainLogic chan bool // 结束命令管道
-	sync.WaitGroup
-}
+const baseUrl = "https://apiv2.bitz.com"
 
 type Ticker struct {
 	Last string `json:"now"`    // 本阶段最新价
 	High string `json:"high"`   // 本阶段最高价
 	Low  string `json:"low"`    // 本阶段最低价
 	Open string `json:"open"`   // 本阶段开盘价
-	Vol  string `json:"volume"` // 以报价币种计量的交易量
+	Vol  string `json:"volume"` // 以基础币种计量的交易量
+
+	Bid     string `json:"bidPrice"`    // 买一价
+	Ask     string `json:"askPrice"`    // 卖一价
+	BidSize string `json:"bidQuantity"` // 买一量
+	AskSize string `json:"askQuantity"` // 卖一量
 }
 
 type ApiResponse struct {
 	Status  int     `json:"status"`
 	Message string  `json:"msg"`
 	Data    *Ticker `json:"data"`
+	Time    int64   `json:"time"` // 服务器时间 秒
 }
 
 var (
@@ -49,106 +43,33 @@ var (
 	}
 )
 
-func NewProvider() *Provider {
-	p := &Provider{
-		readChan:       make(map[string]chan *model.Kline),
-		breakMainLogic: make(chan bool),
-	}
-
-	for _, coinTyp := range config.SupportCoinTypes {
-		p.readChan[coinTyp] = make(chan *model.Kline)
-	}
-
-	return p
-}
-
-func (p *Provider) ReadChan(coinType string) <-chan *model.Kline {
-	return p.readChan[coinType]
-}
-
-func (p *Provider) StartCollect() {
-	for _, coinType := range config.SupportCoinTypes {
-		if _, ok := bitzCoinMap[coinType]; ok {
-			p.Add(1)
-			go func(c string) {
-				defer p.Done()
-				p.loop(c)
-			}(coinType)
-		}
-	}
-}
-
-func (p *Provider) loop(params ...interface{}) {
-	if len(params) <= 0 {
-		return
-	}
-	coinType := params[0].(string)
-	t := time.NewTicker(time.Second)
-	defer t.Stop()
-
-	for {
-		select {
-		case <-t.C:
-
-			ticker, err := p.getTicker(coinType)
-			if err != nil {
-				logger.Error("BitzProvider_getTicker", nil, err.Error())
-				break
-			}
-			now := time.Now().Unix()
-			price := ticker.Last
-			vol := ticker.Vol
-
-			item := &model.Kline{
-				CoinType:    coinType,
-				High:        price,
-				Low:         price,
-				Open:        price,
-				Close:       price,
-				CreateTime:  now,
-				UpdateTime:  now,
-				TimeScale:   "1s",
-				Origin:      constant.ProviderBitzOriginType,
-				OriginPrice: "",
-				Volume:      vol,
-			}
-
-			p.handleKline(coinType, item)
-			break
-		case <-p.breakMainLogic:
-			return
-		}
-	}
+func init() {
+	provider.Register(constant.ProviderBitz, func() provider.Provider { return NewProvider() })
+	provider.RegisterStream(constant.ProviderBitz, func() provider.Provider { return NewStreamProvider() })
 }
 
-func (p *Provider) handleKline(coinType string, kline *model.Kline) {
-	select {
-	case p.readChan[coinType] <- kline:
-
-	case <-time.After(time.Second * constant.ProviderDataExpireTime):
-	case <-p.breakMainLogic:
-	}
+func NewProvider() *provider.PollProvider {
+	return provider.NewPollProvider(provider.PollConfig{
+		Name:       constant.ProviderBitz,
+		Origin:     constant.ProviderBitzOriginType,
+		VolumeUnit: provider.VolumeBase,
+		CoinMap:    bitzCoinMap,
+		Timeout:    5 * time.Second,
+		UserAgent:  "Chrome/39.0.2171.71",
+		BaseUrl:    baseUrl,
+		Url:        tickerUrl,
+		Decode:     decodeTicker,
+	})
 }
 
-func (p *Provider) Stop() {
-	close(p.breakMainLogic)
-	p.Wait()
-}
-
-// request data here
 // 此接口获取ticker信息同时提供最近24小时的交易聚合信息。
-func (p *Provider) getTicker(coinType string) (*Ticker, error) {
-	symbol := bitzCoinMap[coinType]
-	request := gorequest.New()
-	request = request.AppendHeader("User-Agent", "Chrome/39.0.2171.71")
-	url := fmt.Sprintf("https://apiv2.bitz.com/Market/ticker?symbol=%s", symbol)
-	_, body, errs := request.Get(url).Timeout(5 * time.Second).End()
-	if len(errs) > 0 {
-		return nil, errs[0]
-	}
+func tickerUrl(base, symbol string) string {
+	return fmt.Sprintf(base+"/Market/ticker?symbol=%s", symbol)
+}
 
+func decodeTicker(body []byte) (*provider.Tick, error) {
 	resp := &ApiResponse{}
-	if err := json.Unmarshal([]byte(body), resp); err != nil {
+	if err := json.Unmarshal(body, resp); err != nil {
 		return nil, err
 	}
 	if resp.Status != 200 {
@@ -160,5 +81,13 @@ func (p *Provider) getTicker(coinType string) (*Ticker, error) {
 		return nil, errors.New("data invalid")
 	}
 
-	return tick, nil
+	return &provider.Tick{
+		Last:    tick.Last,
+		Vol:     tick.Vol,
+		Time:    resp.Time * 1000,
+		Bid:     tick.Bid,
+		Ask:     tick.Ask,
+		BidSize: tick.BidSize,
+		AskSize: tick.AskSize,
+	}, nil
 }
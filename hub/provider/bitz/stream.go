@@ -0,0 +1,79 @@
+package bitz
+
+import (
+	"bitcoin-kline/constant"
+	"bitcoin-kline/hub/provider"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// websocket文档：https://apidoc.bitz.top/cn/websocket-api/Introduction.html
+// 服务端发送"ping", 客户端需回复"pong"
+
+const wsUrl = "wss://wsapi.bitz.so/"
+
+type streamHandler struct{}
+
+type streamResponse struct {
+	Status int    `json:"status"`
+	Action string `json:"action"`
+	Msg    string `json:"msg"`
+	Params struct {
+		Symbol string `json:"symbol"`
+	} `json:"params"`
+	Data *Ticker `json:"data"`
+}
+
+func NewStreamProvider() *provider.StreamProvider {
+	return provider.NewStreamProvider(provider.StreamConfig{
+		Name:       constant.ProviderBitz,
+		Origin:     constant.ProviderBitzOriginType,
+		VolumeUnit: provider.VolumeBase,
+		CoinMap:    bitzCoinMap,
+		Url:        wsUrl,
+		Handler:    &streamHandler{},
+	})
+}
+
+func (h *streamHandler) Url(base string, symbols []string) string {
+	return base
+}
+
+func (h *streamHandler) Subscribe(symbols []string) []string {
+	msgs := make([]string, 0)
+	for _, symbol := range symbols {
+		msgs = append(msgs, fmt.Sprintf(`{"action":"Topic.sub","data":{"symbol":"%s","type":"market","dataType":"1"},"msg_id":%d}`,
+			symbol, time.Now().UnixNano()/1e6))
+	}
+	return msgs
+}
+
+func (h *streamHandler) Ping() string {
+	return ""
+}
+
+func (h *streamHandler) Decode(msg []byte) ([]*provider.Tick, string, error) {
+	if string(msg) == "ping" {
+		return nil, "pong", nil
+	}
+
+	resp := &streamResponse{}
+	if err := json.Unmarshal(msg, resp); err != nil {
+		return nil, "", err
+	}
+	if resp.Status != 0 && resp.Status != 200 {
+		return nil, "", errors.New(resp.Msg)
+	}
+	if resp.Action != "Pushdata.market" || resp.Data == nil {
+		return nil, "", nil
+	}
+
+	tick := &provider.Tick{
+		Symbol: resp.Params.Symbol,
+		Last:   resp.Data.Last,
+		Vol:    resp.Data.Vol,
+	}
+	return []*provider.Tick{tick}, "", nil
+}
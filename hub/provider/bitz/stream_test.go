@@ -0,0 +1,60 @@
+package bitz
+
+import (
+	"bitcoin-kline/hub/provider"
+	"encoding/json"
+	"testing"
+)
+
+func TestStreamSubscribe(t *testing.T) {
+	msgs := (&streamHandler{}).Subscribe([]string{"eth_usdt", "btc_usdt"})
+	if len(msgs) != 2 {
+		t.Fatalf("unexpected subscribe: %v", msgs)
+	}
+	for i, symbol := range []string{"eth_usdt", "btc_usdt"} {
+		// msg_id为当前时间, 只校验其余字段
+		msg := struct {
+			Action string            `json:"action"`
+			Data   map[string]string `json:"data"`
+			MsgId  int64             `json:"msg_id"`
+		}{}
+		if err := json.Unmarshal([]byte(msgs[i]), &msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg.Action != "Topic.sub" || msg.Data["symbol"] != symbol || msg.Data["type"] != "market" || msg.Data["dataType"] != "1" || msg.MsgId <= 0 {
+			t.Fatalf("unexpected subscribe: %s", msgs[i])
+		}
+	}
+}
+
+func TestStreamDecode(t *testing.T) {
+	cases := []struct {
+		name  string
+		msg   string
+		ticks []provider.Tick
+		reply string
+		err   bool
+	}{
+		{name: "market", msg: `{"status":200,"action":"Pushdata.market","params":{"symbol":"eth_usdt"},"data":{"now":"143.21","high":"144.8","low":"140.9","open":"141.5","volume":"179000.1"},"time":1576209600123}`,
+			ticks: []provider.Tick{{Symbol: "eth_usdt", Last: "143.21", Vol: "179000.1"}}},
+		{name: "ping", msg: "ping", reply: "pong"},
+		{name: "subscribed", msg: `{"status":200,"action":"Topic.sub","msg":"","data":null}`},
+		{name: "api error", msg: `{"status":-102,"action":"Topic.sub","msg":"symbol error"}`, err: true},
+		{name: "truncated", msg: `{"status":200,"action":"Pushdata.market","data":{"now":`, err: true},
+		{name: "wrong type", msg: `{"status":"200"}`, err: true},
+		{name: "empty", msg: "", err: true},
+	}
+	h := &streamHandler{}
+	for _, c := range cases {
+		ticks, reply, err := h.Decode([]byte(c.msg))
+		if (err != nil) != c.err || reply != c.reply || len(ticks) != len(c.ticks) {
+			t.Errorf("%s: unexpected result %v %q %v", c.name, ticks, reply, err)
+			continue
+		}
+		for i, tick := range ticks {
+			if *tick != c.ticks[i] {
+				t.Errorf("%s: expect %+v, got %+v", c.name, c.ticks[i], *tick)
+			}
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package provider_test
+
+import (
+	"bitcoin-kline/constant"
+	"bitcoin-kline/hub/provider"
+	"bitcoin-kline/hub/provider/exchangetest"
+	"bitcoin-kline/model"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 各交易所测试使用的交易对
+var exchangeSymbols = map[string]map[string]string{
+	constant.ProviderHuoBi:   {constant.CoinTypeETHUSDT: "ethusdt", constant.CoinTypeBTCUSDT: "btcusdt"},
+	constant.ProviderOkex:    {constant.CoinTypeETHUSDT: "ETH-USDT", constant.CoinTypeBTCUSDT: "BTC-USDT"},
+	constant.ProviderBinance: {constant.CoinTypeETHUSDT: "ETHUSDT", constant.CoinTypeBTCUSDT: "BTCUSDT"},
+	constant.ProviderZB:      {constant.CoinTypeETHUSDT: "eth_usdt", constant.CoinTypeBTCUSDT: "btc_usdt"},
+	constant.ProviderGateio:  {constant.CoinTypeETHUSDT: "eth_usdt", constant.CoinTypeBTCUSDT: "btc_usdt"},
+	constant.ProviderBitz:    {constant.CoinTypeETHUSDT: "eth_usdt", constant.CoinTypeBTCUSDT: "btc_usdt"},
+	constant.ProviderBitmax:  {constant.CoinTypeETHUSDT: "ETH-USDT", constant.CoinTypeBTCUSDT: "BTC-USDT"},
+	constant.ProviderSina:    {constant.CoinTypeRUCNY: "nf_RU0", constant.CoinTypeCUCNY: "nf_CU0"},
+}
+
+// 行情接口不返回时间的交易所
+var noExchangeTime = map[string]bool{
+	constant.ProviderGateio: true,
+	constant.ProviderBitmax: true,
+}
+
+func TestExchangeProviders(t *testing.T) {
+	server := exchangetest.NewServer()
+	server.Delay = 3 * time.Second
+	defer server.Close()
+
+	for name, symbols := range exchangeSymbols {
+		for _, symbol := range symbols {
+			server.SetPrice(name, symbol, "200.5000", "1000.0000")
+		}
+	}
+	if err := exchangetest.LoadConfig("testdata/exchanges.ini", server.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	// 各交易所并行测试
+	var wg sync.WaitGroup
+	for name, symbols := range exchangeSymbols {
+		wg.Add(1)
+		go func(name string, symbols map[string]string) {
+			defer wg.Done()
+			if err := testExchange(server, name, symbols); err != nil {
+				t.Errorf("%s: %s", name, err.Error())
+			}
+		}(name, symbols)
+	}
+	wg.Wait()
+}
+
+func testExchange(server *exchangetest.Server, name string, symbols map[string]string) error {
+	p, err := provider.New(name)
+	if err != nil {
+		return err
+	}
+	p.StartCollect()
+	defer p.Stop()
+
+	coinType := constant.CoinTypeETHUSDT
+	if _, ok := symbols[coinType]; !ok {
+		coinType = constant.CoinTypeRUCNY
+	}
+	kline, err := expectClose(p, coinType, "200.5000", 5*time.Second)
+	if err != nil {
+		return err
+	}
+	// 交易所时间应接近本地接收时间
+	if _, ok := noExchangeTime[name]; !ok {
+		if kline.ExchangeTime == 0 || math.Abs(float64(kline.ReceiveTime-kline.ExchangeTime)) > 5000 {
+			return fmt.Errorf("unexpected exchange time %d, receive time %d", kline.ExchangeTime, kline.ReceiveTime)
+		}
+	}
+
+	// 各种错误都应记录为采集失败
+	for _, mode := range []exchangetest.ErrorMode{
+		exchangetest.ErrorApi,
+		exchangetest.ErrorMalformed,
+		exchangetest.ErrorStatus,
+		exchangetest.ErrorTimeout,
+		exchangetest.ErrorRateLimit,
+	} {
+		before := failures(name, coinType)
+		server.SetError(name, mode)
+		if err := expectFailure(name, coinType, before); err != nil {
+			return fmt.Errorf("error mode %d: %s", mode, err.Error())
+		}
+	}
+
+	// 恢复后采集到新价格, 限频退避期间不会请求
+	server.SetError(name, exchangetest.ErrorNone)
+	server.SetPrice(name, symbols[coinType], "201.2500", "1000.0000")
+	_, err = expectClose(p, coinType, "201.2500", 20*time.Second)
+	return err
+}
+
+func expectClose(p provider.Provider, coinType, price string, d time.Duration) (*model.Kline, error) {
+	timeout := time.After(d)
+	for {
+		select {
+		case item := <-p.ReadChan(coinType):
+			if item.CoinType != coinType {
+				return nil, fmt.Errorf("unexpected kline: %+v", item)
+			}
+			if item.Close == price {
+				return item, nil
+			}
+		case <-timeout:
+			return nil, fmt.Errorf("no kline with close %s", price)
+		}
+	}
+}
+
+func failures(name, coinType string) int64 {
+	for _, stat := range provider.Health(name) {
+		if stat.CoinType == coinType {
+			return stat.Failures
+		}
+	}
+	return 0
+}
+
+func expectFailure(name, coinType string, before int64) error {
+	timeout := time.After(5 * time.Second)
+	for failures(name, coinType) <= before {
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-timeout:
+			return errors.New("not reported as failure")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package exchangetest
+
+import (
+	"bitcoin-kline/config"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// 加载测试配置文件, 其中的{server}替换为替身地址后写入临时文件, 再经config.LoadConfig加载
+func LoadConfig(fileName, server string) error {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile("", "kline-*.ini")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString(strings.Replace(string(data), "{server}", server, -1))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return config.LoadConfig(f.Name())
+}
@@ -0,0 +1,203 @@
+package exchangetest
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// 各交易所的行情接口, 只返回数据商解析用到的字段
+
+// /market/detail/merged?symbol=ethusdt, /market/tickers
+func serveHuobi(w http.ResponseWriter, r *http.Request, path string, prices map[string]*price) {
+	huobiError := map[string]interface{}{"status": "error", "err-code": "invalid-parameter", "err-msg": "invalid symbol"}
+	switch path {
+	case "/market/detail/merged":
+		symbol := r.URL.Query().Get("symbol")
+		p, ok := prices[symbol]
+		if !ok {
+			writeJson(w, huobiError)
+			return
+		}
+		writeJson(w, map[string]interface{}{
+			"status": "ok",
+			"ch":     "market." + symbol + ".detail.merged",
+			"ts":     msNow(),
+			"tick":   map[string]interface{}{"id": time.Now().Unix(), "close": toFloat(p.last), "amount": toFloat(p.vol)},
+		})
+	case "/market/tickers":
+		if len(prices) == 0 {
+			writeJson(w, huobiError)
+			return
+		}
+		data := make([]map[string]interface{}, 0)
+		for symbol, p := range prices {
+			data = append(data, map[string]interface{}{"symbol": symbol, "close": toFloat(p.last), "amount": toFloat(p.vol)})
+		}
+		writeJson(w, map[string]interface{}{"status": "ok", "ts": msNow(), "data": data})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// /api/spot/v3/instruments/ETH-USDT/ticker, /api/spot/v3/instruments/ticker
+func serveOkex(w http.ResponseWriter, r *http.Request, path string, prices map[string]*price) {
+	okexError := map[string]interface{}{"code": 30032, "message": "pair suspended"}
+	if path == "/api/spot/v3/instruments/ticker" {
+		if len(prices) == 0 {
+			writeJson(w, okexError)
+			return
+		}
+		data := make([]map[string]interface{}, 0)
+		for symbol, p := range prices {
+			data = append(data, map[string]interface{}{"instrument_id": symbol, "last": p.last, "base_volume_24h": p.vol, "timestamp": isoNow()})
+		}
+		writeJson(w, data)
+		return
+	}
+
+	symbol := strings.TrimSuffix(strings.TrimPrefix(path, "/api/spot/v3/instruments/"), "/ticker")
+	if symbol == path {
+		http.NotFound(w, r)
+		return
+	}
+	p, ok := prices[symbol]
+	if !ok {
+		writeJson(w, okexError)
+		return
+	}
+	writeJson(w, map[string]interface{}{"instrument_id": symbol, "last": p.last, "base_volume_24h": p.vol, "timestamp": isoNow()})
+}
+
+// /api/v3/ticker/24hr?symbol=ETHUSDT, /api/v3/ticker/24hr?symbols=["ETHUSDT","BTCUSDT"]
+func serveBinance(w http.ResponseWriter, r *http.Request, path string, prices map[string]*price) {
+	if path != "/api/v3/ticker/24hr" {
+		http.NotFound(w, r)
+		return
+	}
+	binanceError := map[string]interface{}{"code": -1121, "msg": "Invalid symbol."}
+
+	if symbols := r.URL.Query().Get("symbols"); symbols != "" {
+		data := make([]map[string]interface{}, 0)
+		for _, symbol := range strings.Split(strings.Trim(symbols, "[]"), ",") {
+			symbol = strings.Trim(symbol, `"`)
+			p, ok := prices[symbol]
+			if !ok {
+				writeJson(w, binanceError)
+				return
+			}
+			data = append(data, map[string]interface{}{"symbol": symbol, "lastPrice": p.last, "volume": p.vol, "closeTime": msNow()})
+		}
+		writeJson(w, data)
+		return
+	}
+
+	symbol := r.URL.Query().Get("symbol")
+	p, ok := prices[symbol]
+	if !ok {
+		writeJson(w, binanceError)
+		return
+	}
+	writeJson(w, map[string]interface{}{"symbol": symbol, "lastPrice": p.last, "volume": p.vol, "closeTime": msNow()})
+}
+
+// /data/v1/ticker?market=eth_usdt
+func serveZb(w http.ResponseWriter, r *http.Request, path string, prices map[string]*price) {
+	if path != "/data/v1/ticker" {
+		http.NotFound(w, r)
+		return
+	}
+	p, ok := prices[r.URL.Query().Get("market")]
+	if !ok {
+		writeJson(w, map[string]interface{}{"error": "市场错误"})
+		return
+	}
+	writeJson(w, map[string]interface{}{
+		"date":   fmt.Sprint(msNow()),
+		"ticker": map[string]interface{}{"last": p.last, "vol": p.vol},
+	})
+}
+
+// /api2/1/ticker/eth_usdt, /api2/1/tickers
+func serveGateio(w http.ResponseWriter, r *http.Request, path string, prices map[string]*price) {
+	gateioError := map[string]interface{}{"result": "false", "code": 7, "message": "Error: invalid currency pair"}
+	if path == "/api2/1/tickers" {
+		if len(prices) == 0 {
+			writeJson(w, gateioError)
+			return
+		}
+		data := make(map[string]interface{})
+		for symbol, p := range prices {
+			data[symbol] = map[string]interface{}{"result": "true", "last": p.last, "quoteVolume": p.vol}
+		}
+		writeJson(w, data)
+		return
+	}
+
+	symbol := strings.TrimPrefix(path, "/api2/1/ticker/")
+	if symbol == path {
+		http.NotFound(w, r)
+		return
+	}
+	p, ok := prices[symbol]
+	if !ok {
+		writeJson(w, gateioError)
+		return
+	}
+	writeJson(w, map[string]interface{}{"result": "true", "last": p.last, "quoteVolume": p.vol})
+}
+
+// /Market/ticker?symbol=eth_usdt
+func serveBitz(w http.ResponseWriter, r *http.Request, path string, prices map[string]*price) {
+	if path != "/Market/ticker" {
+		http.NotFound(w, r)
+		return
+	}
+	p, ok := prices[r.URL.Query().Get("symbol")]
+	if !ok {
+		writeJson(w, map[string]interface{}{"status": -102, "msg": "Invalid parameter!", "data": nil})
+		return
+	}
+	writeJson(w, map[string]interface{}{
+		"status": 200,
+		"msg":    "",
+		"data":   map[string]interface{}{"now": p.last, "volume": p.vol},
+		"time":   time.Now().Unix(),
+	})
+}
+
+// /api/v1/ticker/24hr?symbol=ETH-USDT
+func serveBitmax(w http.ResponseWriter, r *http.Request, path string, prices map[string]*price) {
+	if path != "/api/v1/ticker/24hr" {
+		http.NotFound(w, r)
+		return
+	}
+	symbol := r.URL.Query().Get("symbol")
+	p, ok := prices[symbol]
+	if !ok {
+		writeJson(w, map[string]interface{}{"code": 100001, "msg": "symbol not found"})
+		return
+	}
+	writeJson(w, map[string]interface{}{"symbol": symbol, "closePrice": p.last, "volume": p.vol})
+}
+
+// /list=nf_RU0,nf_CU0, 每个合约返回一行js变量字符串, 第8项为最新价, 第14项为成交量
+func serveSina(w http.ResponseWriter, r *http.Request, path string, prices map[string]*price) {
+	list := strings.TrimPrefix(path, "/list=")
+	if list == path {
+		http.NotFound(w, r)
+		return
+	}
+	for _, symbol := range strings.Split(list, ",") {
+		p, ok := prices[symbol]
+		if !ok {
+			_, _ = fmt.Fprintf(w, "var hq_str_%s=\"\";\n", symbol)
+			continue
+		}
+		now := time.Now().In(time.FixedZone("CST", 8*3600))
+		fields := []string{"连续", now.Format("150405"), p.last, p.last, p.last, p.last, p.last, p.last, p.last, p.last, p.last,
+			"1", "6", "434190", p.vol, "沪", "期货", now.Format("2006-01-02"), "0"}
+		_, _ = fmt.Fprintf(w, "var hq_str_%s=\"%s\";\n", symbol, strings.Join(fields, ","))
+	}
+}
@@ -0,0 +1,170 @@
+package exchangetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 本地交易所替身, 用于离线测试各数据商的请求与解析
+// 按交易所返回与线上接口一致的行情格式, 地址为 <URL>/<交易所名称>, 配置为数据商的base_url即可
+// 可设置各交易对的价格, 以及模拟接口错误、http错误、限频、响应格式错误与超时
+
+type ErrorMode int
+
+const (
+	ErrorNone      ErrorMode = iota
+	ErrorApi                 // 返回交易所格式的错误信息, http状态码200
+	ErrorStatus              // http 500
+	ErrorRateLimit           // http 429, 带Retry-After
+	ErrorMalformed           // 无法解析的响应
+	ErrorTimeout             // 延迟Delay后才响应
+)
+
+const defaultDelay = 5 * time.Second
+
+type price struct {
+	last string
+	vol  string
+}
+
+type Server struct {
+	*httptest.Server
+	Delay time.Duration // ErrorTimeout时的响应延迟
+
+	prices   map[string]map[string]*price // 交易所 -> 交易对 -> 价格
+	errors   map[string]ErrorMode
+	requests map[string]int
+	sync.Mutex
+}
+
+// path为去掉交易所前缀的请求路径, prices为交易对 -> 价格
+type handler func(w http.ResponseWriter, r *http.Request, path string, prices map[string]*price)
+
+var handlers = map[string]handler{
+	"huobi":   serveHuobi,
+	"okex":    serveOkex,
+	"binance": serveBinance,
+	"zb":      serveZb,
+	"gateio":  serveGateio,
+	"bitz":    serveBitz,
+	"bitmax":  serveBitmax,
+	"sina":    serveSina,
+}
+
+func NewServer() *Server {
+	s := &Server{
+		Delay:    defaultDelay,
+		prices:   make(map[string]map[string]*price),
+		errors:   make(map[string]ErrorMode),
+		requests: make(map[string]int),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// 交易所接口地址
+func (s *Server) Url(exchange string) string {
+	return s.URL + "/" + exchange
+}
+
+// 设置交易对的最新价与成交量, huobi按数值返回, 其余交易所按字符串原样返回
+func (s *Server) SetPrice(exchange, symbol, last, vol string) {
+	s.Lock()
+	defer s.Unlock()
+	if _, ok := s.prices[exchange]; !ok {
+		s.prices[exchange] = make(map[string]*price)
+	}
+	s.prices[exchange][symbol] = &price{last: last, vol: vol}
+}
+
+func (s *Server) SetError(exchange string, mode ErrorMode) {
+	s.Lock()
+	defer s.Unlock()
+	s.errors[exchange] = mode
+}
+
+// 交易所收到的请求次数
+func (s *Server) Requests(exchange string) int {
+	s.Lock()
+	defer s.Unlock()
+	return s.requests[exchange]
+}
+
+// 交易所全部交易对的价格
+func (s *Server) getPrices(exchange string) map[string]*price {
+	s.Lock()
+	defer s.Unlock()
+	prices := make(map[string]*price)
+	for symbol, p := range s.prices[exchange] {
+		prices[symbol] = p
+	}
+	return prices
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	exchange := path
+	if i := strings.Index(path, "/"); i >= 0 {
+		exchange, path = path[:i], path[i:]
+	}
+	h, ok := handlers[exchange]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.Lock()
+	s.requests[exchange]++
+	mode := s.errors[exchange]
+	s.Unlock()
+
+	switch mode {
+	case ErrorStatus:
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprint(w, "internal server error")
+		return
+	case ErrorRateLimit:
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	case ErrorMalformed:
+		_, _ = fmt.Fprint(w, "<html>bad gateway</html>")
+		return
+	case ErrorTimeout:
+		select {
+		case <-time.After(s.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	case ErrorApi:
+		// 按未设置价格处理, 返回交易所格式的错误信息
+		h(w, r, path, map[string]*price{})
+		return
+	}
+	h(w, r, path, s.getPrices(exchange))
+}
+
+func writeJson(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(data)
+}
+
+func toFloat(val string) float64 {
+	n, _ := strconv.ParseFloat(val, 64)
+	return n
+}
+
+// 当前时间 毫秒
+func msNow() int64 {
+	return time.Now().UnixNano() / 1e6
+}
+
+func isoNow() string {
+	return time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+}
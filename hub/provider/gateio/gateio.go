@@ -1,18 +1,11 @@
 package gateio
 
 import (
-	"bitcoin-kline/config"
 	"bitcoin-kline/constant"
-	"bitcoin-kline/logger"
-	"bitcoin-kline/model"
+	"bitcoin-kline/hub/provider"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
-	"sync"
-	"time"
-
-	"github.com/parnurzeal/gorequest"
 )
 
 // 官网：https://www.gate.io/
@@ -21,21 +14,16 @@ import (
 // API域名：https://data.gateio.life/
 // 行情接口：https://www.gate.io/api2#ticker
 
-type Provider struct {
-	readChan map[string]chan *model.Kline
-
-	//currentKline map[string]chan *model.Kline
-
-	breakMainLogic chan bool // 结束命令管道
-	sync.WaitGroup
-}
+const baseUrl = "https://data.gateio.life"
 
 type Ticker struct {
 	Last string `json:"last"`     // 本阶段最新价
 	High string `json:"high24hr"` // 本阶段最高价
 	Low  string `json:"low24hr"`  // 本阶段最低价
 	//Open string `json:"open"`        // 本阶段开盘价
-	Vol string `json:"quoteVolume"` // 以报价币种计量的交易量
+	Vol string `json:"quoteVolume"` // 以基础币种计量的交易量, api2的baseVolume与quoteVolume命名相反
+	Bid string `json:"highestBid"`  // 买一价
+	Ask string `json:"lowestAsk"`   // 卖一价
 }
 
 type ApiResponse struct {
@@ -51,109 +39,33 @@ var (
 	}
 )
 
-func NewProvider() *Provider {
-	p := &Provider{
-		readChan:       make(map[string]chan *model.Kline),
-		breakMainLogic: make(chan bool),
-	}
-
-	for _, coinTyp := range config.SupportCoinTypes {
-		p.readChan[coinTyp] = make(chan *model.Kline)
-	}
-
-	return p
+func init() {
+	provider.Register(constant.ProviderGateio, func() provider.Provider { return NewProvider() })
+	provider.RegisterStream(constant.ProviderGateio, func() provider.Provider { return NewStreamProvider() })
 }
 
-func (p *Provider) ReadChan(coinType string) <-chan *model.Kline {
-	return p.readChan[coinType]
-}
-
-func (p *Provider) StartCollect() {
-	for _, coinType := range config.SupportCoinTypes {
-		if _, ok := coinMap[coinType]; ok {
-			p.Add(1)
-			go func(c string) {
-				defer p.Done()
-				p.loop(c)
-			}(coinType)
-		}
-	}
-}
-
-func (p *Provider) loop(params ...interface{}) {
-	if len(params) <= 0 {
-		return
-	}
-	coinType := params[0].(string)
-	t := time.NewTicker(time.Second)
-	defer t.Stop()
-
-	for {
-		select {
-		case <-t.C:
-
-			ticker, err := p.getTicker(coinType)
-			if err != nil {
-				logger.Error("GateioProvider_getTicker", nil, err.Error())
-				break
-			}
-			now := time.Now().Unix()
-			price := ticker.Last
-			vol := ticker.Vol
-
-			item := &model.Kline{
-				CoinType:    coinType,
-				High:        price,
-				Low:         price,
-				Open:        price,
-				Close:       price,
-				CreateTime:  now,
-				UpdateTime:  now,
-				TimeScale:   "1s",
-				Origin:      constant.ProviderGateioOriginType,
-				OriginPrice: "",
-				Volume:      vol,
-			}
-
-			p.handleKline(coinType, item)
-			break
-		case <-p.breakMainLogic:
-			return
-		}
-	}
+func NewProvider() *provider.PollProvider {
+	return provider.NewPollProvider(provider.PollConfig{
+		Name:        constant.ProviderGateio,
+		Origin:      constant.ProviderGateioOriginType,
+		VolumeUnit:  provider.VolumeBase,
+		CoinMap:     coinMap,
+		BaseUrl:     baseUrl,
+		Url:         tickerUrl,
+		Decode:      decodeTicker,
+		BatchUrl:    tickersUrl,
+		BatchDecode: decodeTickers,
+	})
 }
 
-func (p *Provider) handleKline(coinType string, kline *model.Kline) {
-	select {
-	case p.readChan[coinType] <- kline:
-
-	case <-time.After(time.Second * constant.ProviderDataExpireTime):
-	case <-p.breakMainLogic:
-	}
-}
-
-func (p *Provider) Stop() {
-	close(p.breakMainLogic)
-	p.Wait()
-}
-
-// request data here
 // 此接口获取ticker信息同时提供最近24小时的交易聚合信息。
-func (p *Provider) getTicker(coinType string) (*Ticker, error) {
-	symbol := coinMap[coinType]
-	request := gorequest.New()
-	if os.Getenv("RUNMODE") == "dev" {
-		request = request.Proxy("socks5://127.0.0.1:1088")
-	}
-
-	url := fmt.Sprintf("https://data.gateio.life/api2/1/ticker/%s", symbol)
-	_, body, errs := request.Get(url).Timeout(3 * time.Second).End()
-	if len(errs) > 0 {
-		return nil, errs[0]
-	}
+func tickerUrl(base, symbol string) string {
+	return fmt.Sprintf(base+"/api2/1/ticker/%s", symbol)
+}
 
+func decodeTicker(body []byte) (*provider.Tick, error) {
 	resp := &ApiResponse{}
-	if err := json.Unmarshal([]byte(body), resp); err != nil {
+	if err := json.Unmarshal(body, resp); err != nil {
 		return nil, err
 	}
 	if resp.Code != 0 {
@@ -165,5 +77,41 @@ func (p *Provider) getTicker(coinType string) (*Ticker, error) {
 		return nil, errors.New("data invalid")
 	}
 
-	return tick, nil
+	return &provider.Tick{
+		Last: tick.Last,
+		Vol:  tick.Vol,
+		Bid:  tick.Bid,
+		Ask:  tick.Ask,
+	}, nil
+}
+
+// 所有交易对的行情, 以交易对为key
+func tickersUrl(base string, symbols []string) string {
+	return base + "/api2/1/tickers"
+}
+
+func decodeTickers(body []byte) ([]*provider.Tick, error) {
+	data := make(map[string]*Ticker)
+	if err := json.Unmarshal(body, &data); err != nil {
+		resp := &ApiResponse{}
+		if json.Unmarshal(body, resp) == nil && resp.Code != 0 {
+			return nil, errors.New(resp.Message)
+		}
+		return nil, err
+	}
+
+	ticks := make([]*provider.Tick, 0)
+	for symbol, item := range data {
+		if item == nil {
+			continue
+		}
+		ticks = append(ticks, &provider.Tick{
+			Symbol: symbol,
+			Last:   item.Last,
+			Vol:    item.Vol,
+			Bid:    item.Bid,
+			Ask:    item.Ask,
+		})
+	}
+	return ticks, nil
 }
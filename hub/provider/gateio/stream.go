@@ -0,0 +1,86 @@
+package gateio
+
+import (
+	"bitcoin-kline/constant"
+	"bitcoin-kline/hub/provider"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// websocket文档：https://www.gate.io/docs/websocket/index.html
+// 交易对格式为大写 ETH_USDT, 客户端定时发送server.ping保持连接
+
+const wsUrl = "wss://ws.gate.io/v3/"
+
+type streamHandler struct{}
+
+type streamResponse struct {
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func NewStreamProvider() *provider.StreamProvider {
+	return provider.NewStreamProvider(provider.StreamConfig{
+		Name:       constant.ProviderGateio,
+		Origin:     constant.ProviderGateioOriginType,
+		VolumeUnit: provider.VolumeBase,
+		CoinMap:    coinMap,
+		Url:        wsUrl,
+		Handler:    &streamHandler{},
+	})
+}
+
+func (h *streamHandler) Url(base string, symbols []string) string {
+	return base
+}
+
+func (h *streamHandler) Subscribe(symbols []string) []string {
+	params := make([]string, 0)
+	for _, symbol := range symbols {
+		params = append(params, strings.ToUpper(symbol))
+	}
+	msg, _ := json.Marshal(map[string]interface{}{"id": 1, "method": "ticker.subscribe", "params": params})
+	return []string{string(msg)}
+}
+
+func (h *streamHandler) Ping() string {
+	return `{"id":2,"method":"server.ping","params":[]}`
+}
+
+// 推送格式: {"method":"ticker.update","params":["ETH_USDT",{"last":"...","quoteVolume":"..."}],"id":null}
+func (h *streamHandler) Decode(msg []byte) ([]*provider.Tick, string, error) {
+	resp := &streamResponse{}
+	if err := json.Unmarshal(msg, resp); err != nil {
+		return nil, "", err
+	}
+	if resp.Error != nil {
+		return nil, "", errors.New(resp.Error.Message)
+	}
+	if resp.Method != "ticker.update" {
+		return nil, "", nil
+	}
+	if len(resp.Params) < 2 {
+		return nil, "", errors.New("params invalid")
+	}
+
+	var symbol string
+	if err := json.Unmarshal(resp.Params[0], &symbol); err != nil {
+		return nil, "", err
+	}
+	ticker := &Ticker{}
+	if err := json.Unmarshal(resp.Params[1], ticker); err != nil {
+		return nil, "", err
+	}
+
+	tick := &provider.Tick{
+		Symbol: strings.ToLower(symbol),
+		Last:   ticker.Last,
+		Vol:    ticker.Vol,
+	}
+	return []*provider.Tick{tick}, "", nil
+}
@@ -0,0 +1,50 @@
+package gateio
+
+import (
+	"bitcoin-kline/hub/provider"
+	"testing"
+)
+
+func TestStreamSubscribe(t *testing.T) {
+	h := &streamHandler{}
+	msgs := h.Subscribe([]string{"eth_usdt", "btc_usdt"})
+	if len(msgs) != 1 || msgs[0] != `{"id":1,"method":"ticker.subscribe","params":["ETH_USDT","BTC_USDT"]}` {
+		t.Fatalf("unexpected subscribe: %v", msgs)
+	}
+	if ping := h.Ping(); ping != `{"id":2,"method":"server.ping","params":[]}` {
+		t.Fatalf("unexpected ping: %s", ping)
+	}
+}
+
+func TestStreamDecode(t *testing.T) {
+	cases := []struct {
+		name  string
+		msg   string
+		ticks []provider.Tick
+		err   bool
+	}{
+		{name: "ticker", msg: `{"method":"ticker.update","params":["ETH_USDT",{"period":86400,"open":"141.5","close":"143.21","high":"144.8","low":"140.9","last":"143.21","change":"1.2","quoteVolume":"179000.1","baseVolume":"25632145.12"}],"id":null}`,
+			ticks: []provider.Tick{{Symbol: "eth_usdt", Last: "143.21", Vol: "179000.1"}}},
+		{name: "pong", msg: `{"error":null,"result":"pong","id":2}`},
+		{name: "subscribed", msg: `{"error":null,"result":{"status":"success"},"id":1}`},
+		{name: "api error", msg: `{"error":{"code":1,"message":"invalid argument"},"result":null,"id":1}`, err: true},
+		{name: "short params", msg: `{"method":"ticker.update","params":["ETH_USDT"],"id":null}`, err: true},
+		{name: "bad symbol", msg: `{"method":"ticker.update","params":[1,{"last":"143.21"}],"id":null}`, err: true},
+		{name: "bad ticker", msg: `{"method":"ticker.update","params":["ETH_USDT","143.21"],"id":null}`, err: true},
+		{name: "truncated", msg: `{"method":"ticker.update","params":["ETH_USDT",{`, err: true},
+		{name: "empty", msg: "", err: true},
+	}
+	h := &streamHandler{}
+	for _, c := range cases {
+		ticks, reply, err := h.Decode([]byte(c.msg))
+		if (err != nil) != c.err || reply != "" || len(ticks) != len(c.ticks) {
+			t.Errorf("%s: unexpected result %v %q %v", c.name, ticks, reply, err)
+			continue
+		}
+		for i, tick := range ticks {
+			if *tick != c.ticks[i] {
+				t.Errorf("%s: expect %+v, got %+v", c.name, c.ticks[i], *tick)
+			}
+		}
+	}
+}
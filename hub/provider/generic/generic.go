@@ -0,0 +1,212 @@
+package generic
+
+import (
+	"bitcoin-kline/config"
+	"bitcoin-kline/hub/provider"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 配置定义的通用json数据商, 接入简单的交易所无需改代码
+// 在[provider.<name>]中配置type = generic, 并将name加入[provider]的enabled, 配置项:
+// origin: 数据来源, 必填且不能与已有数据商重复
+// url: 请求地址模板, {symbol}替换为交易对, 如 https://api.example.com/ticker?symbol={symbol}
+// symbols: 币种与交易对映射, 如 ETH/USDT:ethusdt,BTC/USDT:btcusdt
+// last: 最新价的json路径, 必填, 以.分隔, 数组用下标, 如 data.ticker.last、data.0.close
+// volume、high、low、timestamp: 成交量、24小时最高价、最低价、行情时间(毫秒)的json路径, 可选
+// volume_unit: 成交量计量单位, base 基础币种(默认), quote 报价币种
+// bid、ask、bid_size、ask_size: 买一价、卖一价及其数量的json路径, 可选
+// error: 错误信息的json路径, 可选, 值不为空、false或0时视为请求失败
+// 代理、超时、限频等与其他轮询数据商相同
+
+const ProviderGeneric = "generic"
+
+type Paths struct {
+	Last      string
+	Volume    string
+	High      string
+	Low       string
+	Timestamp string
+	Bid       string
+	Ask       string
+	BidSize   string
+	AskSize   string
+	Error     string
+}
+
+func init() {
+	provider.RegisterType(ProviderGeneric, NewProvider)
+}
+
+func NewProvider(name string) (provider.Provider, error) {
+	section := "provider." + name
+	origin := config.GetConfigInt(section, "origin")
+	if origin == 0 {
+		return nil, errors.New("generic provider " + name + " origin required")
+	}
+	url := config.GetConfig(section, "url")
+	if !strings.Contains(url, "{symbol}") {
+		return nil, errors.New("generic provider " + name + " url should contain {symbol}")
+	}
+	coinMap := parseSymbols(config.GetConfig(section, "symbols"))
+	if len(coinMap) == 0 {
+		return nil, errors.New("generic provider " + name + " symbols required")
+	}
+	paths := Paths{
+		Last:      config.GetConfig(section, "last"),
+		Volume:    config.GetConfig(section, "volume"),
+		High:      config.GetConfig(section, "high"),
+		Low:       config.GetConfig(section, "low"),
+		Timestamp: config.GetConfig(section, "timestamp"),
+		Bid:       config.GetConfig(section, "bid"),
+		Ask:       config.GetConfig(section, "ask"),
+		BidSize:   config.GetConfig(section, "bid_size"),
+		AskSize:   config.GetConfig(section, "ask_size"),
+		Error:     config.GetConfig(section, "error"),
+	}
+	if paths.Last == "" {
+		return nil, errors.New("generic provider " + name + " last path required")
+	}
+	if err := provider.RegisterOrigin(origin, name); err != nil {
+		return nil, err
+	}
+
+	return provider.NewPollProvider(provider.PollConfig{
+		Name:       name,
+		Origin:     origin,
+		CoinMap:    coinMap,
+		VolumeUnit: config.GetConfig(section, "volume_unit"),
+		Url: func(base, symbol string) string {
+			return strings.Replace(url, "{symbol}", symbol, -1)
+		},
+		Decode: func(body []byte) (*provider.Tick, error) {
+			return Decode(body, paths)
+		},
+	}), nil
+}
+
+// 币种:交易对, 逗号分隔; 币种本身含有:时以最后一个:分隔
+func parseSymbols(val string) map[string]string {
+	coinMap := make(map[string]string)
+	for _, item := range strings.Split(val, ",") {
+		i := strings.LastIndex(item, ":")
+		if i <= 0 {
+			continue
+		}
+		coinType, symbol := strings.TrimSpace(item[:i]), strings.TrimSpace(item[i+1:])
+		if coinType != "" && symbol != "" {
+			coinMap[coinType] = symbol
+		}
+	}
+	return coinMap
+}
+
+// 按json路径解析行情
+func Decode(body []byte, paths Paths) (*provider.Tick, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var data interface{}
+	if err := decoder.Decode(&data); err != nil {
+		return nil, err
+	}
+
+	if paths.Error != "" {
+		if val, ok := lookup(data, paths.Error); ok && !isEmpty(val) {
+			return nil, errors.New(toString(val))
+		}
+	}
+
+	tick := &provider.Tick{}
+	val, ok := lookup(data, paths.Last)
+	if !ok || isEmpty(val) {
+		return nil, errors.New("path " + paths.Last + " not found")
+	}
+	tick.Last = toString(val)
+	if _, err := strconv.ParseFloat(tick.Last, 64); err != nil {
+		return nil, fmt.Errorf("last price %s invalid", tick.Last)
+	}
+
+	if val, ok := lookup(data, paths.Volume); ok {
+		tick.Vol = toString(val)
+	}
+	if val, ok := lookup(data, paths.High); ok {
+		tick.High = toString(val)
+	}
+	if val, ok := lookup(data, paths.Low); ok {
+		tick.Low = toString(val)
+	}
+	if val, ok := lookup(data, paths.Timestamp); ok {
+		tick.Time, _ = strconv.ParseInt(toString(val), 10, 64)
+	}
+	if val, ok := lookup(data, paths.Bid); ok {
+		tick.Bid = toString(val)
+	}
+	if val, ok := lookup(data, paths.Ask); ok {
+		tick.Ask = toString(val)
+	}
+	if val, ok := lookup(data, paths.BidSize); ok {
+		tick.BidSize = toString(val)
+	}
+	if val, ok := lookup(data, paths.AskSize); ok {
+		tick.AskSize = toString(val)
+	}
+	return tick, nil
+}
+
+// 以.分隔的路径取值, 数组使用下标
+func lookup(data interface{}, path string) (interface{}, bool) {
+	if path == "" {
+		return nil, false
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch val := data.(type) {
+		case map[string]interface{}:
+			v, ok := val[key]
+			if !ok {
+				return nil, false
+			}
+			data = v
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(val) {
+				return nil, false
+			}
+			data = val[i]
+		default:
+			return nil, false
+		}
+	}
+	return data, true
+}
+
+func toString(val interface{}) string {
+	switch v := val.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func isEmpty(val interface{}) bool {
+	switch v := val.(type) {
+	case nil:
+		return true
+	case string:
+		return v == "" || v == "0" || v == "false"
+	case bool:
+		return !v
+	case json.Number:
+		f, err := v.Float64()
+		return err == nil && f == 0
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package generic
+
+import (
+	"bitcoin-kline/constant"
+	"bitcoin-kline/hub/provider"
+	"bitcoin-kline/hub/provider/exchangetest"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDecode(t *testing.T) {
+	paths := Paths{Last: "data.0.last", Volume: "data.0.vol", High: "data.0.high", Timestamp: "ts", Bid: "data.0.bid.0", BidSize: "data.0.bid.1", Error: "error"}
+
+	tick, err := Decode([]byte(`{"error":"","ts":1576209600123,"data":[{"last":"200.51","vol":12.5,"high":"210","bid":[200.5,3]}]}`), paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tick.Last != "200.51" || tick.Vol != "12.5" || tick.High != "210" || tick.Low != "" || tick.Time != 1576209600123 ||
+		tick.Bid != "200.5" || tick.BidSize != "3" || tick.Ask != "" {
+		t.Fatalf("unexpected tick: %+v", tick)
+	}
+
+	if _, err := Decode([]byte(`{"error":"invalid symbol"}`), paths); err == nil || err.Error() != "invalid symbol" {
+		t.Fatalf("expect api error, got %v", err)
+	}
+	if _, err := Decode([]byte(`{"data":[]}`), paths); err == nil {
+		t.Fatal("missing last price should return error")
+	}
+	if _, err := Decode([]byte(`<html>`), paths); err == nil {
+		t.Fatal("invalid json should return error")
+	}
+}
+
+func TestGenericProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"code":0,"ticker":{"symbol":"%s","price":201.25,"amount":"99"}}`, r.URL.Query().Get("market"))
+	}))
+	defer server.Close()
+
+	name := "generic_test"
+	if err := exchangetest.LoadConfig("testdata/generic.ini", server.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := provider.New(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.StartCollect()
+	defer p.Stop()
+
+	select {
+	case item := <-p.ReadChan(constant.CoinTypeETHUSDT):
+		if item.Close != "201.25" || item.Volume != "99" || item.Origin != 300 {
+			t.Fatalf("unexpected kline: %+v", item)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no data from generic provider")
+	}
+	if constant.ProviderOriginMap[300] != name {
+		t.Fatal("origin should be registered")
+	}
+
+	// 来源与已有数据商冲突
+	if _, err := provider.New("generic_dup"); err == nil {
+		t.Fatal("duplicate origin should return error")
+	}
+}
@@ -0,0 +1,146 @@
+package provider
+
+import (
+	"bitcoin-kline/config"
+	"bitcoin-kline/logger"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 数据商健康状态统计
+// 按数据商、币种记录最近成功时间、连续失败次数、错误率与请求耗时
+// 连续失败次数达到配置max_failures时标记为不可用
+
+const (
+	healthWindow       = 100 // 错误率统计最近的请求次数
+	defaultMaxFailures = 10
+)
+
+type HealthStatus struct {
+	Provider            string  `json:"provider"`
+	CoinType            string  `json:"coinType"`
+	Down                bool    `json:"down"`                // 是否不可用
+	LastSuccess         int64   `json:"lastSuccess"`         // 最近一次成功时间
+	LastFailure         int64   `json:"lastFailure"`         // 最近一次失败时间
+	LastError           string  `json:"lastError"`           // 最近一次错误信息
+	ConsecutiveFailures int     `json:"consecutiveFailures"` // 连续失败次数
+	Requests            int64   `json:"requests"`            // 总请求次数
+	Failures            int64   `json:"failures"`            // 总失败次数
+	ErrorRate           float64 `json:"errorRate"`           // 最近100次请求的错误率
+	Latency             int64   `json:"latency"`             // 最近一次请求耗时 毫秒
+	AvgLatency          int64   `json:"avgLatency"`          // 平均请求耗时 毫秒
+}
+
+type healthStat struct {
+	HealthStatus
+	results    [healthWindow]bool // 最近请求结果环形队列, true为失败
+	resultSize int
+	resultPos  int
+}
+
+var (
+	healthStats = make(map[string]map[string]*healthStat) // provider -> coinType -> stat
+	healthLock  sync.RWMutex
+)
+
+// 连续失败多少次标记为不可用, 配置[provider.<name>]或[provider]的max_failures项
+func maxFailures(name string) int {
+	if n := config.GetConfigInt("provider."+name, "max_failures"); n > 0 {
+		return n
+	}
+	if n := config.GetConfigInt("provider", "max_failures"); n > 0 {
+		return n
+	}
+	return defaultMaxFailures
+}
+
+func getHealthStat(name, coinType string) *healthStat {
+	stats, ok := healthStats[name]
+	if !ok {
+		stats = make(map[string]*healthStat)
+		healthStats[name] = stats
+	}
+	stat, ok := stats[coinType]
+	if !ok {
+		stat = &healthStat{HealthStatus: HealthStatus{Provider: name, CoinType: coinType}}
+		stats[coinType] = stat
+	}
+	return stat
+}
+
+func (s *healthStat) record(failed bool, latency time.Duration) {
+	s.Requests++
+	s.Latency = int64(latency / time.Millisecond)
+	// 平均耗时按指数加权计算, 突出最近的请求
+	if s.Requests == 1 {
+		s.AvgLatency = s.Latency
+	} else {
+		s.AvgLatency = (s.AvgLatency*9 + s.Latency) / 10
+	}
+
+	s.results[s.resultPos] = failed
+	s.resultPos = (s.resultPos + 1) % healthWindow
+	if s.resultSize < healthWindow {
+		s.resultSize++
+	}
+	count := 0
+	for i := 0; i < s.resultSize; i++ {
+		if s.results[i] {
+			count++
+		}
+	}
+	s.ErrorRate = float64(count) / float64(s.resultSize)
+}
+
+// 记录一次成功的采集
+func ReportSuccess(name, coinType string, latency time.Duration) {
+	healthLock.Lock()
+	defer healthLock.Unlock()
+
+	stat := getHealthStat(name, coinType)
+	stat.record(false, latency)
+	stat.LastSuccess = time.Now().Unix()
+	stat.ConsecutiveFailures = 0
+	if stat.Down {
+		stat.Down = false
+		logger.Info("ProviderHealth_recover", map[string]string{"provider": name, "coinType": coinType}, "provider recovered")
+	}
+}
+
+// 记录一次失败的采集
+func ReportFailure(name, coinType string, latency time.Duration, err error) {
+	healthLock.Lock()
+	defer healthLock.Unlock()
+
+	stat := getHealthStat(name, coinType)
+	stat.record(true, latency)
+	stat.LastFailure = time.Now().Unix()
+	stat.LastError = err.Error()
+	stat.Failures++
+	stat.ConsecutiveFailures++
+	if !stat.Down && stat.ConsecutiveFailures >= maxFailures(name) {
+		stat.Down = true
+		logger.Error("ProviderHealth_down", map[string]interface{}{"provider": name, "coinType": coinType, "failures": stat.ConsecutiveFailures}, "provider down: "+stat.LastError)
+	}
+}
+
+// 数据商健康状态, 按数据商、币种排序
+func Health(names ...string) []HealthStatus {
+	healthLock.RLock()
+	defer healthLock.RUnlock()
+
+	list := make([]HealthStatus, 0)
+	for _, name := range names {
+		for _, stat := range healthStats[name] {
+			list = append(list, stat.HealthStatus)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Provider != list[j].Provider {
+			return list[i].Provider < list[j].Provider
+		}
+		return list[i].CoinType < list[j].CoinType
+	})
+	return list
+}
@@ -0,0 +1,35 @@
+package provider_test
+
+import (
+	"bitcoin-kline/constant"
+	"bitcoin-kline/hub/provider"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHealth(t *testing.T) {
+	name := "health_test"
+	provider.ReportSuccess(name, constant.CoinTypeETHUSDT, 20*time.Millisecond)
+	for i := 0; i < 10; i++ {
+		provider.ReportFailure(name, constant.CoinTypeETHUSDT, 10*time.Millisecond, errors.New("timeout"))
+	}
+
+	list := provider.Health(name)
+	if len(list) != 1 {
+		t.Fatalf("unexpected status count: %d", len(list))
+	}
+	status := list[0]
+	if !status.Down || status.ConsecutiveFailures != 10 || status.Failures != 10 || status.Requests != 11 {
+		t.Fatalf("provider should be down: %+v", status)
+	}
+	if status.ErrorRate < 0.9 || status.ErrorRate > 0.91 || status.LastError != "timeout" {
+		t.Fatalf("unexpected error rate: %+v", status)
+	}
+
+	provider.ReportSuccess(name, constant.CoinTypeETHUSDT, 10*time.Millisecond)
+	status = provider.Health(name)[0]
+	if status.Down || status.ConsecutiveFailures != 0 || status.Latency != 10 {
+		t.Fatalf("provider should recover: %+v", status)
+	}
+}
@@ -0,0 +1,130 @@
+package provider
+
+import (
+	"bitcoin-kline/model"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// 交易所历史k线, 用于服务中断后补数据
+// 统一按1分钟k线拉取, 更大刻度由写库时的upsert聚合
+// 请求沿用[provider.<name>]的代理、域名、超时与限频配置
+
+const HistoryInterval = 60 // 历史k线间隔 秒
+
+type Candle struct {
+	Time  int64 // 开盘时间 秒
+	Open  string
+	High  string
+	Low   string
+	Close string
+	Vol   string
+}
+
+type HistoryConfig struct {
+	Name       string            // 数据商名称
+	Origin     int               // 数据来源
+	CoinMap    map[string]string // 币种 -> 交易所交易对
+	BaseUrl    string            // 接口域名, 配置项base_url
+	Limit      int               // 单次请求最多返回的k线数量, 必须大于0
+	VolumeUnit string            // 成交量计量单位, VolumeBase(默认)或VolumeQuote
+
+	Url    func(base, symbol string, start, end int64) string // 构造请求地址, 时间为秒, 左闭右开
+	Decode func(body []byte) ([]*Candle, error)               // 解析响应
+}
+
+var histories = make(map[string]HistoryConfig)
+
+// 注册历史k线接口, 重复注册会panic
+func RegisterHistory(conf HistoryConfig) {
+	if _, ok := histories[conf.Name]; ok {
+		panic("history " + conf.Name + " registered twice")
+	}
+	histories[conf.Name] = conf
+}
+
+// 是否提供历史k线接口
+func HasHistory(name string) bool {
+	_, ok := histories[name]
+	return ok
+}
+
+// 拉取[start, end)内的1分钟k线, 按时间正序, 超出单次数量限制时分段请求
+func FetchHistory(name, coinType string, start, end int64) ([]*model.Kline, error) {
+	conf, ok := histories[name]
+	if !ok {
+		return nil, errors.New("provider " + name + " has no history api")
+	}
+	symbol, ok := conf.CoinMap[coinType]
+	if !ok {
+		return nil, errors.New("provider " + name + " not support " + coinType)
+	}
+	if conf.Limit <= 0 {
+		return nil, errors.New("provider " + name + " history limit invalid")
+	}
+
+	pollConf := PollConfig{Name: name, BaseUrl: conf.BaseUrl}
+	pollConf.loadConfig()
+	if pollConf.Timeout == 0 {
+		pollConf.Timeout = defaultPollTimeout
+	}
+	client := newHttpClient(pollConf)
+	limiter := NewRateLimiter(pollConf.RateLimit, pollConf.Burst)
+
+	start -= start % HistoryInterval
+	step := int64(conf.Limit) * HistoryInterval
+	candles := make(map[int64]*Candle)
+	for from := start; from < end; from += step {
+		to := from + step
+		if to > end {
+			to = end
+		}
+		limiter.Wait(nil)
+		resp, err := request(name, client, limiter, pollConf.Retries, conf.Url(pollConf.BaseUrl, symbol, from, to), nil)
+		if err != nil {
+			return nil, err
+		}
+		list, err := conf.Decode(resp.Body)
+		if resp.StatusCode >= http.StatusBadRequest {
+			return nil, statusError(resp.StatusCode, err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decode history %s-%d: %s", symbol, from, err.Error())
+		}
+		// 部分交易所不支持按时间查询, 只取区间内的数据并去重
+		for _, candle := range list {
+			if candle.Time >= start && candle.Time < end {
+				candles[candle.Time] = candle
+			}
+		}
+	}
+
+	// k线成交量统一为基础币种
+	klines := make([]*model.Kline, 0)
+	for _, candle := range candles {
+		base, quote := NormalizeVolume(candle.Vol, candle.Close, conf.VolumeUnit)
+		if base == "" {
+			base, quote = "0", "0"
+		}
+		klines = append(klines, &model.Kline{
+			CoinType:    coinType,
+			High:        candle.High,
+			Low:         candle.Low,
+			Open:        candle.Open,
+			Close:       candle.Close,
+			CreateTime:  candle.Time,
+			UpdateTime:  candle.Time,
+			TimeScale:   "1",
+			Origin:      conf.Origin,
+			OriginPrice: candle.Close,
+			Volume:      base,
+			QuoteVolume: quote,
+		})
+	}
+	sort.Slice(klines, func(i, j int) bool {
+		return klines[i].CreateTime < klines[j].CreateTime
+	})
+	return klines, nil
+}
@@ -0,0 +1,83 @@
+package provider_test
+
+import (
+	"bitcoin-kline/constant"
+	"bitcoin-kline/hub/provider"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestFetchHistory(t *testing.T) {
+	// 每次最多返回2根k线, 区间外与重复的数据需丢弃
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		start, _ := strconv.ParseInt(r.URL.Query().Get("start"), 10, 64)
+		lines := []string{fmt.Sprintf("%d,%d", start-60, start-60)}
+		for ts := start; ts < start+120; ts += 60 {
+			lines = append(lines, fmt.Sprintf("%d,%d", ts, ts))
+		}
+		_, _ = fmt.Fprint(w, strings.Join(lines, ";"))
+	}))
+	defer server.Close()
+
+	name := "history_test"
+	provider.RegisterHistory(provider.HistoryConfig{
+		Name:    name,
+		Origin:  constant.ProviderMockOriginType,
+		CoinMap: map[string]string{constant.CoinTypeETHUSDT: "ethusdt"},
+		BaseUrl: server.URL,
+		Limit:   2,
+		Url: func(base, symbol string, start, end int64) string {
+			return fmt.Sprintf("%s/kline?symbol=%s&start=%d&end=%d", base, symbol, start, end)
+		},
+		Decode: func(body []byte) ([]*provider.Candle, error) {
+			candles := make([]*provider.Candle, 0)
+			for _, line := range strings.Split(string(body), ";") {
+				data := strings.Split(line, ",")
+				if len(data) != 2 {
+					return nil, errors.New("body data err")
+				}
+				ts, _ := strconv.ParseInt(data[0], 10, 64)
+				candles = append(candles, &provider.Candle{Time: ts, Open: data[1], High: data[1], Low: data[1], Close: data[1], Vol: "1"})
+			}
+			return candles, nil
+		},
+	})
+
+	klines, err := provider.FetchHistory(name, constant.CoinTypeETHUSDT, 600, 900)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests != 3 {
+		t.Fatalf("expect 3 requests, got %d", requests)
+	}
+	if len(klines) != 5 {
+		t.Fatalf("expect 5 klines, got %d", len(klines))
+	}
+	for i, item := range klines {
+		ts := int64(600 + i*60)
+		if item.CreateTime != ts || item.Close != strconv.FormatInt(ts, 10) || item.TimeScale != "1" {
+			t.Fatalf("unexpected kline %d: %+v", i, item)
+		}
+	}
+
+	if _, err := provider.FetchHistory(name, constant.CoinTypeBTCUSDT, 600, 900); err == nil {
+		t.Fatal("unsupported coin type should return error")
+	}
+
+	// 未配置单次数量限制时无法分段
+	provider.RegisterHistory(provider.HistoryConfig{
+		Name:    "history_no_limit",
+		CoinMap: map[string]string{constant.CoinTypeETHUSDT: "ethusdt"},
+		BaseUrl: server.URL,
+	})
+	if _, err := provider.FetchHistory("history_no_limit", constant.CoinTypeETHUSDT, 600, 900); err == nil {
+		t.Fatal("zero limit should return error")
+	}
+}
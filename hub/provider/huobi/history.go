@@ -0,0 +1,54 @@
+package huobi
+
+import (
+	"bitcoin-kline/constant"
+	"bitcoin-kline/hub/provider"
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+func init() {
+	provider.RegisterHistory(provider.HistoryConfig{
+		Name:       constant.ProviderHuoBi,
+		Origin:     constant.ProviderHuoBiOriginType,
+		VolumeUnit: provider.VolumeBase,
+		CoinMap:    huobiCoinMap,
+		BaseUrl:    baseUrl,
+		Limit:      2000,
+		Url:        klineUrl,
+		Decode:     decodeKline,
+	})
+}
+
+// 不支持按时间查询, 只能获取最近2000根1分钟k线
+func klineUrl(base, symbol string, start, end int64) string {
+	return base + "/market/history/kline?period=1min&size=2000&symbol=" + symbol
+}
+
+func decodeKline(body []byte) ([]*provider.Candle, error) {
+	resp := &struct {
+		Status   string    `json:"status"`
+		ErrorMsg string    `json:"err-msg"`
+		Data     []*Ticker `json:"data"`
+	}{}
+	if err := json.Unmarshal(body, resp); err != nil {
+		return nil, err
+	}
+	if resp.Status != "ok" {
+		return nil, errors.New(resp.ErrorMsg)
+	}
+
+	candles := make([]*provider.Candle, 0)
+	for _, item := range resp.Data {
+		candles = append(candles, &provider.Candle{
+			Time:  item.Id,
+			Open:  strconv.FormatFloat(item.Open, 'f', 4, 64),
+			High:  strconv.FormatFloat(item.High, 'f', 4, 64),
+			Low:   strconv.FormatFloat(item.Low, 'f', 4, 64),
+			Close: strconv.FormatFloat(item.Close, 'f', 4, 64),
+			Vol:   strconv.FormatFloat(item.Amount, 'f', 4, 64),
+		})
+	}
+	return candles, nil
+}
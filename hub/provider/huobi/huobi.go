@@ -1,17 +1,11 @@
 package huobi
 
 import (
-	"bitcoin-kline/config"
 	"bitcoin-kline/constant"
-	"bitcoin-kline/logger"
-	"bitcoin-kline/model"
+	"bitcoin-kline/hub/provider"
 	"encoding/json"
-	"os"
 	"strconv"
-	"sync"
-	"time"
 
-	"github.com/parnurzeal/gorequest"
 	"github.com/pkg/errors"
 )
 
@@ -21,29 +15,25 @@ import (
 // 限频：10秒100次
 // api接口：https://huobiapi.github.io/docs/spot/v1/cn/#ticker
 
-type Provider struct {
-	readChan map[string]chan *model.Kline
-
-	breakMainLogic chan bool // 结束命令管道
-	sync.WaitGroup
-}
+const baseUrl = "https://api-aws.huobi.pro"
 
 type Ticker struct {
-	Id    int64   `json:"id"`
-	Close float64 `json:"close"` // 本阶段最新价
-	Open  float64 `json:"open"`  // 本阶段开盘价
-	High  float64 `json:"high"`  // 本阶段最高价
-	Low   float64 `json:"low"`   // 本阶段最低价
-	//Amount float64    `json:"amount"` // 以基础币种计量的交易量
-	Count int     `json:"count"` // 交易次数
-	Vol   float64 `json:"vol"`   // 以报价币种计量的交易量
-	//Ask    []string `json:"ask"`    // 当前的最低卖价 [price, quote volume]
-	//Bid    []string `json:"bid"`    // 当前的最高买价 [price, quote volume]
+	Id     int64     `json:"id"`
+	Close  float64   `json:"close"`  // 本阶段最新价
+	Open   float64   `json:"open"`   // 本阶段开盘价
+	High   float64   `json:"high"`   // 本阶段最高价
+	Low    float64   `json:"low"`    // 本阶段最低价
+	Amount float64   `json:"amount"` // 以基础币种计量的交易量
+	Count  int       `json:"count"`  // 交易次数
+	Vol    float64   `json:"vol"`    // 以报价币种计量的交易量
+	Ask    []float64 `json:"ask"`    // 当前的最低卖价 [price, quote volume]
+	Bid    []float64 `json:"bid"`    // 当前的最高买价 [price, quote volume]
 }
 
 type ApiResponse struct {
 	Status   string  `json:"status"`
 	ErrorMsg string  `json:"err-msg"`
+	Ts       int64   `json:"ts"` // 响应生成时间 毫秒
 	Ticker   *Ticker `json:"tick"`
 }
 
@@ -54,119 +44,102 @@ var (
 	}
 )
 
-func NewProvider() *Provider {
-	p := &Provider{
-		readChan:       make(map[string]chan *model.Kline),
-		breakMainLogic: make(chan bool),
-	}
-
-	for _, coinTyp := range config.SupportCoinTypes {
-		if _, ok := huobiCoinMap[coinTyp]; ok {
-			p.readChan[coinTyp] = make(chan *model.Kline)
-		}
-	}
-
-	return p
+func init() {
+	provider.Register(constant.ProviderHuoBi, func() provider.Provider { return NewProvider() })
+	provider.RegisterStream(constant.ProviderHuoBi, func() provider.Provider { return NewStreamProvider() })
+	provider.RegisterTrade(constant.ProviderHuoBi, func() provider.Provider { return NewTradeProvider() })
 }
 
-func (p *Provider) ReadChan(coinType string) <-chan *model.Kline {
-	return p.readChan[coinType]
+func NewProvider() *provider.PollProvider {
+	return provider.NewPollProvider(provider.PollConfig{
+		Name:        constant.ProviderHuoBi,
+		Origin:      constant.ProviderHuoBiOriginType,
+		VolumeUnit:  provider.VolumeBase,
+		CoinMap:     huobiCoinMap,
+		BaseUrl:     baseUrl,
+		RateLimit:   10,
+		Url:         tickerUrl,
+		Decode:      decodeTicker,
+		BatchUrl:    tickersUrl,
+		BatchDecode: decodeTickers,
+	})
 }
 
-func (p *Provider) StartCollect() {
-	for _, coinType := range config.SupportCoinTypes {
-		if _, ok := huobiCoinMap[coinType]; ok {
-			p.Add(1)
-			go func(c string) {
-				defer p.Done()
-				p.loop(c)
-			}(coinType)
-		}
-	}
+// 此接口获取ticker信息同时提供最近24小时的交易聚合信息。
+func tickerUrl(base, symbol string) string {
+	return base + "/market/detail/merged?symbol=" + symbol
 }
 
-func (p *Provider) loop(params ...interface{}) {
-	if len(params) <= 0 {
-		return
+func decodeTicker(body []byte) (*provider.Tick, error) {
+	resp := &ApiResponse{}
+	if err := json.Unmarshal(body, resp); err != nil {
+		return nil, err
 	}
-	coinType := params[0].(string)
-	t := time.NewTicker(time.Second)
-	defer t.Stop()
-
-	for {
-		select {
-		case <-t.C:
-
-			ticker, err := p.getTicker(coinType)
-			if err != nil {
-				logger.Error("HuobiProvider_getTicker", coinType, err.Error())
-				break
-			}
-			now := time.Now().Unix()
-			price := strconv.FormatFloat(ticker.Close, 'f', 4, 64)
-			vol := strconv.FormatFloat(ticker.Vol, 'f', 4, 64)
-
-			item := &model.Kline{
-				CoinType:    coinType,
-				High:        price,
-				Low:         price,
-				Open:        price,
-				Close:       price,
-				CreateTime:  now,
-				UpdateTime:  now,
-				TimeScale:   "1s",
-				Origin:      constant.ProviderHuoBiOriginType,
-				OriginPrice: "",
-				Volume:      vol,
-			}
-
-			p.handleKline(coinType, item)
-			break
-		case <-p.breakMainLogic:
-			return
-		}
+	if resp.Status != "ok" {
+		return nil, errors.New(resp.ErrorMsg)
 	}
-}
 
-func (p *Provider) handleKline(coinType string, kline *model.Kline) {
-	select {
-	case p.readChan[coinType] <- kline:
+	tick := resp.Ticker
+	if tick == nil {
+		return nil, errors.New("data invalid")
+	}
 
-	case <-time.After(time.Second * constant.ProviderDataExpireTime):
-	case <-p.breakMainLogic:
+	result := &provider.Tick{
+		Last: strconv.FormatFloat(tick.Close, 'f', 4, 64),
+		Vol:  strconv.FormatFloat(tick.Amount, 'f', 4, 64),
+		Time: resp.Ts,
 	}
+	if len(tick.Bid) == 2 && len(tick.Ask) == 2 {
+		result.Bid = strconv.FormatFloat(tick.Bid[0], 'f', 4, 64)
+		result.BidSize = strconv.FormatFloat(tick.Bid[1], 'f', 4, 64)
+		result.Ask = strconv.FormatFloat(tick.Ask[0], 'f', 4, 64)
+		result.AskSize = strconv.FormatFloat(tick.Ask[1], 'f', 4, 64)
+	}
+	return result, nil
 }
 
-func (p *Provider) Stop() {
-	close(p.breakMainLogic)
-	p.Wait()
+// 所有交易对的最新24小时行情
+func tickersUrl(base string, symbols []string) string {
+	return base + "/market/tickers"
 }
 
-// request data here
-// 此接口获取ticker信息同时提供最近24小时的交易聚合信息。
-func (p *Provider) getTicker(coinType string) (*Ticker, error) {
-	symbol := huobiCoinMap[coinType]
-	request := gorequest.New()
-	if os.Getenv("RUNMODE") == "dev" || config.CURMODE == "dev" {
-		request = request.Proxy("socks5://127.0.0.1:1088")
-	}
-	_, body, errs := request.Get("https://api-aws.huobi.pro/market/detail/merged?symbol=" + symbol).Timeout(3 * time.Second).End()
-	if len(errs) > 0 {
-		return nil, errs[0]
-	}
-
-	resp := &ApiResponse{}
-	if err := json.Unmarshal([]byte(body), resp); err != nil {
+func decodeTickers(body []byte) ([]*provider.Tick, error) {
+	resp := &struct {
+		Status   string `json:"status"`
+		ErrorMsg string `json:"err-msg"`
+		Ts       int64  `json:"ts"`
+		Data     []*struct {
+			Symbol string `json:"symbol"`
+			Ticker
+			// 批量接口的买卖价为单独字段
+			Bid     float64 `json:"bid"`
+			BidSize float64 `json:"bidSize"`
+			Ask     float64 `json:"ask"`
+			AskSize float64 `json:"askSize"`
+		} `json:"data"`
+	}{}
+	if err := json.Unmarshal(body, resp); err != nil {
 		return nil, err
 	}
 	if resp.Status != "ok" {
 		return nil, errors.New(resp.ErrorMsg)
 	}
 
-	tick := resp.Ticker
-	if tick == nil {
-		return nil, errors.New("data invalid")
+	ticks := make([]*provider.Tick, 0)
+	for _, item := range resp.Data {
+		tick := &provider.Tick{
+			Symbol: item.Symbol,
+			Last:   strconv.FormatFloat(item.Close, 'f', 4, 64),
+			Vol:    strconv.FormatFloat(item.Amount, 'f', 4, 64),
+			Time:   resp.Ts,
+		}
+		if item.Bid > 0 && item.Ask > 0 {
+			tick.Bid = strconv.FormatFloat(item.Bid, 'f', 4, 64)
+			tick.BidSize = strconv.FormatFloat(item.BidSize, 'f', 4, 64)
+			tick.Ask = strconv.FormatFloat(item.Ask, 'f', 4, 64)
+			tick.AskSize = strconv.FormatFloat(item.AskSize, 'f', 4, 64)
+		}
+		ticks = append(ticks, tick)
 	}
-
-	return tick, nil
+	return ticks, nil
 }
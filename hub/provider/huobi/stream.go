@@ -0,0 +1,97 @@
+package huobi
+
+import (
+	"bitcoin-kline/constant"
+	"bitcoin-kline/hub/provider"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// websocket文档：https://huobiapi.github.io/docs/spot/v1/cn/#websocket
+// 推送数据均经过gzip压缩, 服务端定时发送{"ping": ts}, 需回复{"pong": ts}
+
+const wsUrl = "wss://api-aws.huobi.pro/ws"
+
+type streamHandler struct{}
+
+type streamResponse struct {
+	Ping     int64   `json:"ping"`
+	Status   string  `json:"status"`
+	ErrorMsg string  `json:"err-msg"`
+	Ch       string  `json:"ch"`
+	Ts       int64   `json:"ts"` // 推送时间 毫秒
+	Ticker   *Ticker `json:"tick"`
+}
+
+func NewStreamProvider() *provider.StreamProvider {
+	return provider.NewStreamProvider(provider.StreamConfig{
+		Name:       constant.ProviderHuoBi,
+		Origin:     constant.ProviderHuoBiOriginType,
+		VolumeUnit: provider.VolumeBase,
+		CoinMap:    huobiCoinMap,
+		Url:        wsUrl,
+		Handler:    &streamHandler{},
+	})
+}
+
+func (h *streamHandler) Url(base string, symbols []string) string {
+	return base
+}
+
+// 订阅市场概要 market.$symbol.detail
+func (h *streamHandler) Subscribe(symbols []string) []string {
+	msgs := make([]string, 0)
+	for _, symbol := range symbols {
+		msgs = append(msgs, fmt.Sprintf(`{"sub":"market.%s.detail","id":"%s"}`, symbol, symbol))
+	}
+	return msgs
+}
+
+func (h *streamHandler) Ping() string {
+	return ""
+}
+
+func (h *streamHandler) Decode(msg []byte) ([]*provider.Tick, string, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(msg))
+	if err != nil {
+		return nil, "", err
+	}
+	body, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp := &streamResponse{}
+	if err := json.Unmarshal(body, resp); err != nil {
+		return nil, "", err
+	}
+	if resp.Ping != 0 {
+		return nil, fmt.Sprintf(`{"pong":%d}`, resp.Ping), nil
+	}
+	if resp.Status == "error" {
+		return nil, "", errors.New(resp.ErrorMsg)
+	}
+	if resp.Ticker == nil {
+		return nil, "", nil
+	}
+
+	// ch格式: market.ethusdt.detail
+	s := strings.Split(resp.Ch, ".")
+	if len(s) != 3 {
+		return nil, "", errors.New("channel invalid: " + resp.Ch)
+	}
+	tick := &provider.Tick{
+		Symbol: s[1],
+		Last:   strconv.FormatFloat(resp.Ticker.Close, 'f', 4, 64),
+		Vol:    strconv.FormatFloat(resp.Ticker.Amount, 'f', 4, 64),
+		Time:   resp.Ts,
+	}
+	return []*provider.Tick{tick}, "", nil
+}
@@ -0,0 +1,112 @@
+package huobi
+
+import (
+	"bitcoin-kline/hub/provider"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"testing"
+)
+
+// 抓取的推送帧, gzip压缩
+const (
+	// {"ch":"market.ethusdt.detail","ts":1576209600123,"tick":{"id":205123456,"close":143.21,"open":141.5,"high":144.8,"low":140.9,"amount":179000.1234,"count":80321,"vol":25632145.123}}
+	detailFrame = "H4sIAAAAAAACAyWNQQ6DIBBF7/LXZDIgoHIbo6QQUZqKdmG8eyHdzXv5eXNjDnDYps/qC/kSzmMptPgyxQSBcsBJ01vFo2WWqqsqzivcjbjAKTbVaWMF5pQPX8e6IyUF8tvvjSQZgRBfoYGmQSDlb7uZRoFpy+deKvYjM1Nr1dLfDdy10JVT/WNsBW3a4nl+mVvaaLQAAAA="
+	// {"ch":"market.ethusdt.trade.detail","ts":1576209600456,"tick":{"id":101,"ts":1576209600450,"data":[{"amount":0.5,"ts":1576209600450,...,"price":143.2,"direction":"buy"},{"amount":1.25,"ts":1576209600452,...,"price":143.22,"direction":"sell"}]}}
+	tradeFrame = "H4sIAAAAAAAC/23OTQrCMBAF4Lu8dQhp+iPmBp5BXMRkoKF/kk4XUnJ3owil2uXMe3wzK1wLg8HGjlgSt8vsWXK0nqQntqGHAM8wRX1qtDo3SlV1k1fBdTArgs+RKv47SsBbtjDXFXaYlpFhlKwPi1/kw7wvX/KslRZ4xOAoZ1Up8+RDJMdhGvPD9+WJJDa6kPrA1putd3a5t3/wmfoe6ZbSC0UWRH4eAQAA"
+)
+
+func frame(t *testing.T, val string) []byte {
+	data, err := base64.StdEncoding.DecodeString(val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func gzipFrame(body string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, _ = w.Write([]byte(body))
+	_ = w.Close()
+	return buf.Bytes()
+}
+
+func TestStreamSubscribe(t *testing.T) {
+	msgs := (&streamHandler{}).Subscribe([]string{"ethusdt", "btcusdt"})
+	expect := []string{`{"sub":"market.ethusdt.detail","id":"ethusdt"}`, `{"sub":"market.btcusdt.detail","id":"btcusdt"}`}
+	if len(msgs) != len(expect) || msgs[0] != expect[0] || msgs[1] != expect[1] {
+		t.Fatalf("unexpected subscribe: %v", msgs)
+	}
+	msgs = (&tradeHandler{}).Subscribe([]string{"ethusdt"})
+	if len(msgs) != 1 || msgs[0] != `{"sub":"market.ethusdt.trade.detail","id":"ethusdt"}` {
+		t.Fatalf("unexpected trade subscribe: %v", msgs)
+	}
+}
+
+func TestStreamDecode(t *testing.T) {
+	detail := frame(t, detailFrame)
+	cases := []struct {
+		name  string
+		msg   []byte
+		ticks []provider.Tick
+		reply string
+		err   bool
+	}{
+		{name: "detail", msg: detail, ticks: []provider.Tick{{Symbol: "ethusdt", Last: "143.2100", Vol: "179000.1234", Time: 1576209600123}}},
+		{name: "ping", msg: gzipFrame(`{"ping":1576209600000}`), reply: `{"pong":1576209600000}`},
+		{name: "subscribed", msg: gzipFrame(`{"id":"ethusdt","status":"ok","subbed":"market.ethusdt.detail","ts":1576209600000}`)},
+		{name: "api error", msg: gzipFrame(`{"status":"error","err-code":"bad-request","err-msg":"invalid topic"}`), err: true},
+		{name: "bad channel", msg: gzipFrame(`{"ch":"market.detail","tick":{"close":1}}`), err: true},
+		{name: "truncated", msg: detail[:len(detail)/2], err: true},
+		{name: "not gzip", msg: []byte(`{"ch":"market.ethusdt.detail"}`), err: true},
+		{name: "invalid json", msg: gzipFrame(`{"ch":`), err: true},
+		{name: "empty", msg: nil, err: true},
+	}
+	h := &streamHandler{}
+	for _, c := range cases {
+		ticks, reply, err := h.Decode(c.msg)
+		if (err != nil) != c.err || reply != c.reply || len(ticks) != len(c.ticks) {
+			t.Errorf("%s: unexpected result %v %q %v", c.name, ticks, reply, err)
+			continue
+		}
+		for i, tick := range ticks {
+			if *tick != c.ticks[i] {
+				t.Errorf("%s: expect %+v, got %+v", c.name, c.ticks[i], *tick)
+			}
+		}
+	}
+}
+
+func TestTradeDecode(t *testing.T) {
+	trade := frame(t, tradeFrame)
+	cases := []struct {
+		name   string
+		msg    []byte
+		trades []provider.Trade
+		reply  string
+		err    bool
+	}{
+		{name: "trade", msg: trade, trades: []provider.Trade{
+			{Symbol: "ethusdt", Price: "143.2", Size: "0.5", Side: provider.SideBuy, Time: 1576209600450},
+			{Symbol: "ethusdt", Price: "143.22", Size: "1.25", Side: provider.SideSell, Time: 1576209600452},
+		}},
+		{name: "ping", msg: gzipFrame(`{"ping":1576209600000}`), reply: `{"pong":1576209600000}`},
+		{name: "bad channel", msg: gzipFrame(`{"ch":"market.ethusdt.detail","tick":{"data":[]}}`), err: true},
+		{name: "truncated", msg: trade[:len(trade)-10], err: true},
+		{name: "not gzip", msg: []byte("ping"), err: true},
+	}
+	h := &tradeHandler{}
+	for _, c := range cases {
+		trades, reply, err := h.Decode(c.msg)
+		if (err != nil) != c.err || reply != c.reply || len(trades) != len(c.trades) {
+			t.Errorf("%s: unexpected result %v %q %v", c.name, trades, reply, err)
+			continue
+		}
+		for i, item := range trades {
+			if *item != c.trades[i] {
+				t.Errorf("%s: expect %+v, got %+v", c.name, c.trades[i], *item)
+			}
+		}
+	}
+}
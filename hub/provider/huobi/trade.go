@@ -0,0 +1,103 @@
+package huobi
+
+import (
+	"bitcoin-kline/constant"
+	"bitcoin-kline/hub/provider"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// 逐笔成交 market.$symbol.trade.detail, 一条推送可包含多笔成交
+// {"ch":"market.btcusdt.trade.detail","ts":1489474082831,"tick":{"id":14650745135,"ts":1533265950234,"data":[{"amount":0.0099,"ts":1533265950234,"id":146507451359183894799,"tradeId":102043495674,"price":401.74,"direction":"buy"}]}}
+
+type tradeHandler struct{}
+
+type tradeResponse struct {
+	Ping     int64  `json:"ping"`
+	Status   string `json:"status"`
+	ErrorMsg string `json:"err-msg"`
+	Ch       string `json:"ch"`
+	Tick     *struct {
+		Data []struct {
+			Amount    float64 `json:"amount"` // 以基础币种计量
+			Ts        int64   `json:"ts"`     // 成交时间 毫秒
+			Price     float64 `json:"price"`
+			Direction string  `json:"direction"` // 主动成交方向 buy/sell
+		} `json:"data"`
+	} `json:"tick"`
+}
+
+func NewTradeProvider() *provider.StreamProvider {
+	return provider.NewStreamProvider(provider.StreamConfig{
+		Name:    constant.ProviderHuoBi,
+		Origin:  constant.ProviderHuoBiOriginType,
+		CoinMap: huobiCoinMap,
+		Url:     wsUrl,
+		Trade:   &tradeHandler{},
+	})
+}
+
+func (h *tradeHandler) Url(base string, symbols []string) string {
+	return base
+}
+
+func (h *tradeHandler) Subscribe(symbols []string) []string {
+	msgs := make([]string, 0)
+	for _, symbol := range symbols {
+		msgs = append(msgs, fmt.Sprintf(`{"sub":"market.%s.trade.detail","id":"%s"}`, symbol, symbol))
+	}
+	return msgs
+}
+
+func (h *tradeHandler) Ping() string {
+	return ""
+}
+
+func (h *tradeHandler) Decode(msg []byte) ([]*provider.Trade, string, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(msg))
+	if err != nil {
+		return nil, "", err
+	}
+	body, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp := &tradeResponse{}
+	if err := json.Unmarshal(body, resp); err != nil {
+		return nil, "", err
+	}
+	if resp.Ping != 0 {
+		return nil, fmt.Sprintf(`{"pong":%d}`, resp.Ping), nil
+	}
+	if resp.Status == "error" {
+		return nil, "", errors.New(resp.ErrorMsg)
+	}
+	if resp.Tick == nil {
+		return nil, "", nil
+	}
+
+	// ch格式: market.ethusdt.trade.detail
+	s := strings.Split(resp.Ch, ".")
+	if len(s) != 4 {
+		return nil, "", errors.New("channel invalid: " + resp.Ch)
+	}
+	trades := make([]*provider.Trade, 0, len(resp.Tick.Data))
+	for _, item := range resp.Tick.Data {
+		trades = append(trades, &provider.Trade{
+			Symbol: s[1],
+			Price:  strconv.FormatFloat(item.Price, 'f', -1, 64),
+			Size:   strconv.FormatFloat(item.Amount, 'f', -1, 64),
+			Side:   item.Direction,
+			Time:   item.Ts,
+		})
+	}
+	return trades, "", nil
+}
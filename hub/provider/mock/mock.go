@@ -3,120 +3,237 @@ package mock
 import (
 	"bitcoin-kline/config"
 	"bitcoin-kline/constant"
-	"bitcoin-kline/model"
+	"bitcoin-kline/hub/provider"
+	"bitcoin-kline/logger"
+	"errors"
+	"math"
 	"math/rand"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
 
 // 本地mock 开发测试使用
-type Provider struct {
-	readChan       map[string]chan *model.Kline
-	breakMainLogic chan bool // 结束命令管道
-	sync.WaitGroup
-}
+// 每个币种独立的几何布朗运动价格, 可注入价格尖峰、价格不变、服务中断与响应延迟等场景
+// 可启用多个实例, [provider.<name>]配置type = mock, 配置项:
+// origin: 数据来源, 默认100
+// seed: 随机数种子, 不配置则按启动时间生成; 多个实例种子相同时走势一致
+// price: 起始价格, 如 ETH/USDT:200,BTC/USDT:7000
+// drift: 年化漂移率, 默认0, 可按币种配置
+// volatility: 年化波动率, 默认0.8, 可按币种配置
+// noise: 在走势上叠加的独立噪声(相对标准差), 默认0
+// spread: 买卖价差(相对价格), 默认0.001
+// scenario: 场景脚本, 见scenario.go
+// scenario_cycle: 场景循环周期 秒, 默认0不循环
+
+const (
+	defaultVolatility = 0.8
+	defaultSpread     = 0.001
+	secondsPerYear    = 365 * 86400
+)
 
-type Ticker struct {
-	High   string `json:"high"`
-	Open   string `json:"open"`
-	Low    string `json:"low"`
-	Close  string `json:"close"`
-	Sell   string `json:"sell"`
-	Buy    string `json:"buy"`
-	Volume string `json:"volume"`
+var defaultPrices = map[string]float64{
+	constant.CoinTypeETHUSDT: 200,
+	constant.CoinTypeBTCUSDT: 7000,
+	constant.CoinTypeRUCNY:   12000,
+	constant.CoinTypeCUCNY:   48000,
+	constant.CoinTypeMCNY:    2800,
 }
 
-var current = 100.00
+func init() {
+	provider.Register(constant.ProviderMock, func() provider.Provider { return NewProvider(constant.ProviderMock) })
+	provider.RegisterType(constant.ProviderMock, func(name string) (provider.Provider, error) {
+		if origin := config.GetConfigInt("provider."+name, "origin"); origin != 0 {
+			if err := provider.RegisterOrigin(origin, name); err != nil {
+				return nil, err
+			}
+		}
+		return NewProvider(name), nil
+	})
+}
 
-func NewProvider() *Provider {
-	p := &Provider{
-		readChan:       make(map[string]chan *model.Kline),
-		breakMainLogic: make(chan bool),
+func NewProvider(name string) *provider.PollProvider {
+	coinMap := make(map[string]string)
+	for _, coinType := range config.SupportCoinTypes {
+		coinMap[coinType] = coinType
 	}
 
-	for _, coinTyp := range config.SupportCoinTypes {
-		p.readChan[coinTyp] = make(chan *model.Kline)
+	origin := config.GetConfigInt("provider."+name, "origin")
+	if origin == 0 {
+		origin = constant.ProviderMockOriginType
 	}
 
-	return p
+	return provider.NewPollProvider(provider.PollConfig{
+		Name:       name,
+		Origin:     origin,
+		VolumeUnit: provider.VolumeBase,
+		CoinMap:    coinMap,
+		Fetch:      NewMock(name).getTicker,
+	})
 }
 
-func (p *Provider) ReadChan(coinType string) <-chan *model.Kline {
-	return p.readChan[coinType]
+// 单个币种的价格走势, 按启动后的秒数推进, 与请求次数无关
+type walker struct {
+	price      float64
+	drift      float64 // 年化漂移率
+	volatility float64 // 年化波动率
+	noise      float64
+	steps      int64      // 已推进的秒数
+	rand       *rand.Rand // 走势随机数
+	noiseRand  *rand.Rand // 噪声与成交量随机数, 与走势分开以保证同种子实例的走势一致
 }
 
-func (p *Provider) StartCollect() {
-	for _, coinType := range config.SupportCoinTypes {
-		p.Add(1)
-		go func(c string) {
-			defer p.Done()
-			p.loop(c)
-		}(coinType)
+// 以1秒为步长推进到第seconds秒, 返回叠加噪声后的价格
+func (w *walker) advance(seconds int64) float64 {
+	dt := 1.0 / secondsPerYear
+	for ; w.steps < seconds; w.steps++ {
+		w.price *= math.Exp((w.drift-w.volatility*w.volatility/2)*dt + w.volatility*math.Sqrt(dt)*w.rand.NormFloat64())
+	}
+	if w.noise == 0 {
+		return w.price
 	}
+	return w.price * (1 + w.noise*w.noiseRand.NormFloat64())
 }
 
-func (p *Provider) loop(params ...interface{}) {
-	if len(params) <= 0 {
-		return
-	}
-	coinType := params[0].(string)
-	t := time.NewTicker(time.Second)
-	defer t.Stop()
-
-	for {
-		select {
-		case <-t.C:
-			ticker, _ := p.getTicker(coinType)
-			now := time.Now().Unix()
-			item := &model.Kline{
-				CoinType:    coinType,
-				High:        ticker.Close,
-				Low:         ticker.Close,
-				Open:        ticker.Close,
-				Close:       ticker.Close,
-				CreateTime:  now,
-				UpdateTime:  now,
-				TimeScale:   "1s",
-				Origin:      constant.ProviderMockOriginType,
-				OriginPrice: ticker.Close,
-				Volume:      ticker.Volume,
-			}
+type Mock struct {
+	name      string
+	walkers   map[string]*walker // 币种 -> 价格走势
+	last      map[string]float64 // 币种 -> 最近一次报价
+	scenarios []*Scenario
+	spread    float64
+	cycle     int64
+	start     time.Time
 
-			p.handleKline(coinType, item)
-			break
-		case <-p.breakMainLogic:
-			return
+	sync.Mutex
+}
+
+func NewMock(name string) *Mock {
+	section := "provider." + name
+	seed := config.GetConfigInt64(section, "seed")
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	scenarios, err := ParseScenarios(config.GetConfig(section, "scenario"))
+	if err != nil {
+		logger.Error("Mock_parseScenarios", name, err.Error())
+	}
+
+	m := &Mock{
+		name:      name,
+		walkers:   make(map[string]*walker),
+		last:      make(map[string]float64),
+		scenarios: scenarios,
+		spread:    defaultSpread,
+		cycle:     config.GetConfigInt64(section, "scenario_cycle"),
+		start:     time.Now(),
+	}
+
+	if config.GetConfig(section, "spread") != "" {
+		m.spread = config.GetConfigFloat64(section, "spread")
+	}
+
+	prices := parseCoinValues(config.GetConfig(section, "price"))
+	drifts := parseCoinValues(config.GetConfig(section, "drift"))
+	volatilities := parseCoinValues(config.GetConfig(section, "volatility"))
+	noise := config.GetConfigFloat64(section, "noise")
+	for i, coinType := range config.SupportCoinTypes {
+		w := &walker{
+			price:      coinValue(prices, coinType, defaultPrices[coinType]),
+			drift:      coinValue(drifts, coinType, 0),
+			volatility: coinValue(volatilities, coinType, defaultVolatility),
+			noise:      noise,
+			rand:       rand.New(rand.NewSource(seed + int64(i))),
+			noiseRand:  rand.New(rand.NewSource(time.Now().UnixNano() + int64(i))),
+		}
+		if w.price <= 0 {
+			w.price = 100
 		}
+		m.walkers[coinType] = w
 	}
+	return m
 }
 
-func (p *Provider) handleKline(coinType string, kline *model.Kline) {
-	select {
-	case p.readChan[coinType] <- kline:
-	case <-time.After(time.Second * constant.ProviderDataExpireTime):
-	case <-p.breakMainLogic:
+// mock data here, 行情时间为请求时间, 延迟场景下返回的报价带有真实的时长
+func (m *Mock) getTicker(coinType string) (*provider.Tick, error) {
+	now := time.Now()
+	scenario := m.active(now)
+	if scenario != nil {
+		switch scenario.Kind {
+		case ScenarioOutage:
+			return nil, errors.New("mock outage")
+		case ScenarioDelay:
+			time.Sleep(time.Duration(scenario.Param * float64(time.Second)))
+		}
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	w, ok := m.walkers[coinType]
+	if !ok {
+		return nil, errors.New("mock not support " + coinType)
+	}
+
+	price := w.advance(int64(now.Sub(m.start) / time.Second))
+	if last, ok := m.last[coinType]; ok && scenario != nil && scenario.Kind == ScenarioFlat {
+		price = last
+	}
+	m.last[coinType] = price
+	if scenario != nil && scenario.Kind == ScenarioSpike {
+		price *= 1 + scenario.Param
+	}
+
+	return &provider.Tick{
+		Symbol:  coinType,
+		Last:    strconv.FormatFloat(price, 'f', 4, 64),
+		Vol:     strconv.Itoa(w.noiseRand.Intn(2000) + 1000),
+		Time:    now.UnixNano() / 1e6,
+		Bid:     strconv.FormatFloat(price*(1-m.spread/2), 'f', 4, 64),
+		Ask:     strconv.FormatFloat(price*(1+m.spread/2), 'f', 4, 64),
+		BidSize: strconv.Itoa(w.noiseRand.Intn(100) + 1),
+		AskSize: strconv.Itoa(w.noiseRand.Intn(100) + 1),
+	}, nil
+}
+
+// 当前生效的场景
+func (m *Mock) active(now time.Time) *Scenario {
+	offset := int64(now.Sub(m.start) / time.Second)
+	if m.cycle > 0 {
+		offset %= m.cycle
+	}
+	for _, scenario := range m.scenarios {
+		if offset >= scenario.Start && offset < scenario.Start+scenario.Duration {
+			return scenario
+		}
 	}
+	return nil
 }
 
-func (p *Provider) Stop() {
-	close(p.breakMainLogic)
-	p.Wait()
+// 解析按币种配置的数值, 如 ETH/USDT:200,BTC/USDT:7000, 单个数值对所有币种生效, key为空
+func parseCoinValues(val string) map[string]float64 {
+	values := make(map[string]float64)
+	for _, item := range strings.Split(val, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		coinType := ""
+		if i := strings.LastIndex(item, ":"); i >= 0 {
+			coinType, item = strings.TrimSpace(item[:i]), item[i+1:]
+		}
+		if n, err := strconv.ParseFloat(strings.TrimSpace(item), 64); err == nil {
+			values[coinType] = n
+		}
+	}
+	return values
 }
 
-// mock data here
-func (p *Provider) getTicker(coinType string) (*Ticker, error) {
-	op := rand.Intn(21) - 10
-	current = current + rand.Float64()*float64(op)/10
-	price := strconv.FormatFloat(current, 'f', 4, 64)
-	vol := strconv.Itoa(rand.Intn(2000) + 1000)
-	ticker := Ticker{
-		High:   price,
-		Open:   price,
-		Low:    price,
-		Close:  price,
-		Volume: vol,
-	}
-
-	return &ticker, nil
+func coinValue(values map[string]float64, coinType string, def float64) float64 {
+	if n, ok := values[coinType]; ok {
+		return n
+	}
+	if n, ok := values[""]; ok {
+		return n
+	}
+	return def
 }
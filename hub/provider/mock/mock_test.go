@@ -0,0 +1,93 @@
+package mock
+
+import (
+	"bitcoin-kline/constant"
+	"math/rand"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func newTestMock(seed int64, scenario string, cycle int64) *Mock {
+	scenarios, _ := ParseScenarios(scenario)
+	m := &Mock{
+		walkers:   make(map[string]*walker),
+		last:      make(map[string]float64),
+		scenarios: scenarios,
+		cycle:     cycle,
+		start:     time.Now(),
+	}
+	m.walkers[constant.CoinTypeETHUSDT] = &walker{
+		price:      200,
+		volatility: defaultVolatility,
+		rand:       rand.New(rand.NewSource(seed)),
+		noiseRand:  rand.New(rand.NewSource(seed)),
+	}
+	return m
+}
+
+func TestParseScenarios(t *testing.T) {
+	scenarios, err := ParseScenarios("spike:30:5:0.1, flat:60:20,outage:90:15,delay:120:10:4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scenarios) != 4 || scenarios[0].Kind != ScenarioSpike || scenarios[0].Param != 0.1 || scenarios[3].Duration != 10 {
+		t.Fatalf("unexpected scenarios: %+v", scenarios)
+	}
+
+	for _, val := range []string{"spike:30:5", "crash:1:2", "flat:a:2"} {
+		if _, err := ParseScenarios(val); err == nil {
+			t.Fatalf("scenario %s should be invalid", val)
+		}
+	}
+}
+
+func TestWalker(t *testing.T) {
+	// 相同种子的走势一致, 与请求次数无关
+	a := newTestMock(1, "", 0).walkers[constant.CoinTypeETHUSDT]
+	b := newTestMock(1, "", 0).walkers[constant.CoinTypeETHUSDT]
+	a.advance(10)
+	if a.advance(20) != b.advance(20) {
+		t.Fatal("walkers with same seed should be equal")
+	}
+	if a.price == 200 || a.price < 190 || a.price > 210 {
+		t.Fatalf("unexpected price after 20 seconds: %f", a.price)
+	}
+}
+
+func TestScenario(t *testing.T) {
+	m := newTestMock(1, "spike:0:10:0.5,outage:10:10", 20)
+
+	tick, err := m.getTicker(constant.CoinTypeETHUSDT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	price, _ := strconv.ParseFloat(tick.Last, 64)
+	if price < 290 || price > 310 {
+		t.Fatalf("spike price should be around 300, got %f", price)
+	}
+
+	// 第10秒起服务中断, 周期20秒后重新出现尖峰
+	if _, err := m.getTicker(constant.CoinTypeBTCUSDT); err == nil {
+		t.Fatal("unsupported coin type should return error")
+	}
+	m.start = time.Now().Add(-15 * time.Second)
+	if _, err := m.getTicker(constant.CoinTypeETHUSDT); err == nil {
+		t.Fatal("outage scenario should return error")
+	}
+	m.start = time.Now().Add(-25 * time.Second)
+	if s := m.active(time.Now()); s == nil || s.Kind != ScenarioSpike {
+		t.Fatalf("scenario should repeat every cycle, got %+v", s)
+	}
+
+	// 延迟的报价时间为请求时间
+	m = newTestMock(1, "delay:0:10:0.2", 0)
+	start := time.Now().UnixNano() / 1e6
+	tick, err = m.getTicker(constant.CoinTypeETHUSDT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if age := time.Now().UnixNano()/1e6 - tick.Time; tick.Time < start || age < 200 {
+		t.Fatalf("delayed tick should keep request time, got %d, age %dms", tick.Time, age)
+	}
+}
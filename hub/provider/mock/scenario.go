@@ -0,0 +1,76 @@
+package mock
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// 场景脚本, 逗号分隔, 每项为 类型:开始秒数:持续秒数[:参数], 时间相对于启动时间
+// spike:30:5:0.1   第30秒起5秒内报价上浮10%(参数为负则下跌)
+// flat:60:20       第60秒起20秒内报价不变
+// outage:90:15     第90秒起15秒内请求失败
+// delay:120:10:4   第120秒起10秒内每次请求延迟4秒, 报价时间为请求时间, 延迟超过[provider]的freshness时按过期报价丢弃
+// 多个场景时间重叠时取先配置的
+
+const (
+	ScenarioSpike  = "spike"
+	ScenarioFlat   = "flat"
+	ScenarioOutage = "outage"
+	ScenarioDelay  = "delay"
+)
+
+type Scenario struct {
+	Kind     string
+	Start    int64   // 开始时间 秒
+	Duration int64   // 持续时间 秒
+	Param    float64 // spike为涨跌幅, delay为延迟秒数
+}
+
+func ParseScenarios(val string) ([]*Scenario, error) {
+	scenarios := make([]*Scenario, 0)
+	for _, item := range strings.Split(val, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		scenario, err := parseScenario(item)
+		if err != nil {
+			return scenarios, err
+		}
+		scenarios = append(scenarios, scenario)
+	}
+	return scenarios, nil
+}
+
+func parseScenario(item string) (*Scenario, error) {
+	fields := strings.Split(item, ":")
+	if len(fields) < 3 {
+		return nil, errors.New("scenario " + item + " format err")
+	}
+
+	scenario := &Scenario{Kind: fields[0]}
+	var err error
+	if scenario.Start, err = strconv.ParseInt(fields[1], 10, 64); err != nil {
+		return nil, errors.New("scenario " + item + " start err")
+	}
+	if scenario.Duration, err = strconv.ParseInt(fields[2], 10, 64); err != nil {
+		return nil, errors.New("scenario " + item + " duration err")
+	}
+	if len(fields) > 3 {
+		if scenario.Param, err = strconv.ParseFloat(fields[3], 64); err != nil {
+			return nil, errors.New("scenario " + item + " param err")
+		}
+	}
+
+	switch scenario.Kind {
+	case ScenarioFlat, ScenarioOutage:
+	case ScenarioSpike, ScenarioDelay:
+		if len(fields) < 4 {
+			return nil, errors.New("scenario " + item + " param required")
+		}
+	default:
+		return nil, errors.New("scenario " + scenario.Kind + " not support")
+	}
+	return scenario, nil
+}
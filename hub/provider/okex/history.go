@@ -0,0 +1,63 @@
+package okex
+
+import (
+	"bitcoin-kline/constant"
+	"bitcoin-kline/hub/provider"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+func init() {
+	provider.RegisterHistory(provider.HistoryConfig{
+		Name:       constant.ProviderOkex,
+		Origin:     constant.ProviderOkexOriginType,
+		VolumeUnit: provider.VolumeQuote,
+		CoinMap:    okCoinMap,
+		BaseUrl:    baseUrl,
+		Limit:      200,
+		Url:        candlesUrl,
+		Decode:     decodeCandles,
+	})
+}
+
+// 1分钟k线, 时间参数为ISO8601, 单次最多200根
+func candlesUrl(base, symbol string, start, end int64) string {
+	return fmt.Sprintf("%s/api/spot/v3/instruments/%s/candles?granularity=60&start=%s&end=%s", base, symbol,
+		url.QueryEscape(time.Unix(start, 0).UTC().Format(time.RFC3339)),
+		url.QueryEscape(time.Unix(end-1, 0).UTC().Format(time.RFC3339)))
+}
+
+// [开盘时间, 开盘价, 最高价, 最低价, 收盘价, 成交量], 按时间倒序
+func decodeCandles(body []byte) ([]*provider.Candle, error) {
+	list := make([][]string, 0)
+	if err := json.Unmarshal(body, &list); err != nil {
+		resp := &ApiResponse{}
+		if json.Unmarshal(body, resp) == nil && resp.Code != 0 {
+			return nil, errors.New(resp.Message)
+		}
+		return nil, err
+	}
+
+	candles := make([]*provider.Candle, 0)
+	for _, item := range list {
+		if len(item) < 6 {
+			return nil, errors.New("candle data err")
+		}
+		t, err := time.Parse(time.RFC3339, item[0])
+		if err != nil {
+			return nil, err
+		}
+		candles = append(candles, &provider.Candle{
+			Time:  t.Unix(),
+			Open:  item[1],
+			High:  item[2],
+			Low:   item[3],
+			Close: item[4],
+			Vol:   item[5],
+		})
+	}
+	return candles, nil
+}
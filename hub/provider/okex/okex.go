@@ -1,17 +1,12 @@
 package okex
 
 import (
-	"bitcoin-kline/config"
 	"bitcoin-kline/constant"
-	"bitcoin-kline/logger"
-	"bitcoin-kline/model"
+	"bitcoin-kline/hub/provider"
 	"encoding/json"
 	"fmt"
-	"os"
-	"sync"
 	"time"
 
-	"github.com/parnurzeal/gorequest"
 	"github.com/pkg/errors"
 )
 
@@ -21,21 +16,20 @@ import (
 // 接口：https://www.okex.com/docs/zh/#spot-some
 // 交易对参考：https://www.okex.com/docs/zh/#spot-currency
 
-type Provider struct {
-	readChan map[string]chan *model.Kline
-
-	//currentKline map[string]chan *model.Kline
-
-	breakMainLogic chan bool // 结束命令管道
-	sync.WaitGroup
-}
+const baseUrl = "https://www.okex.com"
 
 type Ticker struct {
-	Last string `json:"last"`             // 本阶段最新价
-	High string `json:"high_24h"`         // 本阶段最高价
-	Low  string `json:"low_24h"`          // 本阶段最低价
-	Open string `json:"open_24h"`         // 本阶段开盘价
-	Vol  string `json:"quote_volume_24h"` // 以报价币种计量的交易量
+	Last string `json:"last"`            // 本阶段最新价
+	High string `json:"high_24h"`        // 本阶段最高价
+	Low  string `json:"low_24h"`         // 本阶段最低价
+	Open string `json:"open_24h"`        // 本阶段开盘价
+	Vol  string `json:"base_volume_24h"` // 以基础币种计量的交易量
+	Time string `json:"timestamp"`       // 行情时间 ISO8601
+
+	Bid     string `json:"best_bid"`      // 买一价
+	Ask     string `json:"best_ask"`      // 卖一价
+	BidSize string `json:"best_bid_size"` // 买一量
+	AskSize string `json:"best_ask_size"` // 卖一量
 }
 
 type ApiResponse struct {
@@ -51,121 +45,97 @@ var (
 	}
 )
 
-func NewProvider() *Provider {
-	p := &Provider{
-		readChan:       make(map[string]chan *model.Kline),
-		breakMainLogic: make(chan bool),
-	}
-
-	for _, coinTyp := range config.SupportCoinTypes {
-		if _, ok := okCoinMap[coinTyp]; ok {
-			p.readChan[coinTyp] = make(chan *model.Kline)
-		}
-	}
-
-	return p
+func init() {
+	provider.Register(constant.ProviderOkex, func() provider.Provider { return NewProvider() })
+	provider.RegisterStream(constant.ProviderOkex, func() provider.Provider { return NewStreamProvider() })
+	provider.RegisterTrade(constant.ProviderOkex, func() provider.Provider { return NewTradeProvider() })
 }
 
-func (p *Provider) ReadChan(coinType string) <-chan *model.Kline {
-	return p.readChan[coinType]
+func NewProvider() *provider.PollProvider {
+	return provider.NewPollProvider(provider.PollConfig{
+		Name:        constant.ProviderOkex,
+		Origin:      constant.ProviderOkexOriginType,
+		VolumeUnit:  provider.VolumeBase,
+		CoinMap:     okCoinMap,
+		BaseUrl:     baseUrl,
+		RateLimit:   6,
+		Url:         tickerUrl,
+		Decode:      decodeTicker,
+		BatchUrl:    tickersUrl,
+		BatchDecode: decodeTickers,
+	})
 }
 
-func (p *Provider) StartCollect() {
-	for _, coinType := range config.SupportCoinTypes {
-		if _, ok := okCoinMap[coinType]; ok {
-			p.Add(1)
-			go func(c string) {
-				defer p.Done()
-				p.loop(c)
-			}(coinType)
-		}
-	}
+// 此接口获取ticker信息同时提供最近24小时的交易聚合信息。
+func tickerUrl(base, symbol string) string {
+	return fmt.Sprintf(base+"/api/spot/v3/instruments/%s/ticker", symbol)
 }
 
-func (p *Provider) loop(params ...interface{}) {
-	if len(params) <= 0 {
-		return
+func decodeTicker(body []byte) (*provider.Tick, error) {
+	resp := &ApiResponse{}
+	if err := json.Unmarshal(body, resp); err != nil {
+		return nil, err
 	}
-	coinType := params[0].(string)
-	t := time.NewTicker(time.Second)
-	defer t.Stop()
-
-	for {
-		select {
-		case <-t.C:
-
-			ticker, err := p.getTicker(coinType)
-			if err != nil {
-				logger.Error("OkexProvider_getTicker", coinType, err.Error())
-				break
-			}
-			now := time.Now().Unix()
-			price := ticker.Last
-			vol := ticker.Vol
-
-			item := &model.Kline{
-				CoinType:    coinType,
-				High:        price,
-				Low:         price,
-				Open:        price,
-				Close:       price,
-				CreateTime:  now,
-				UpdateTime:  now,
-				TimeScale:   "1s",
-				Origin:      constant.ProviderOkexOriginType,
-				OriginPrice: "",
-				Volume:      vol,
-			}
-
-			p.handleKline(coinType, item)
-			break
-		case <-p.breakMainLogic:
-			return
-		}
+	if resp.Code != 0 {
+		return nil, errors.New(resp.Message)
 	}
-}
-
-func (p *Provider) handleKline(coinType string, kline *model.Kline) {
-	select {
-	case p.readChan[coinType] <- kline:
-
-	case <-time.After(time.Second * constant.ProviderDataExpireTime):
 
+	tick := resp.Ticker
+	if tick == nil {
+		return nil, errors.New("data invalid")
 	}
-}
 
-func (p *Provider) Stop() {
-	close(p.breakMainLogic)
-	p.Wait()
+	return &provider.Tick{
+		Last:    tick.Last,
+		Vol:     tick.Vol,
+		Time:    parseTime(tick.Time),
+		Bid:     tick.Bid,
+		Ask:     tick.Ask,
+		BidSize: tick.BidSize,
+		AskSize: tick.AskSize,
+	}, nil
 }
 
-// request data here
-// 此接口获取ticker信息同时提供最近24小时的交易聚合信息。
-func (p *Provider) getTicker(coinType string) (*Ticker, error) {
-	symbol := okCoinMap[coinType]
-	request := gorequest.New()
-	if os.Getenv("RUNMODE") == "dev" || config.CURMODE == "dev" {
-		request = request.Proxy("socks5://127.0.0.1:1088")
-	}
-
-	url := fmt.Sprintf("https://www.okex.com/api/spot/v3/instruments/%s/ticker", symbol)
-	_, body, errs := request.Get(url).Timeout(3 * time.Second).End()
-	if len(errs) > 0 {
-		return nil, errs[0]
-	}
+// 获取全部交易对的ticker信息
+func tickersUrl(base string, symbols []string) string {
+	return base + "/api/spot/v3/instruments/ticker"
+}
 
-	resp := &ApiResponse{}
-	if err := json.Unmarshal([]byte(body), resp); err != nil {
+// 成功时返回数组, 失败时返回错误对象
+func decodeTickers(body []byte) ([]*provider.Tick, error) {
+	list := make([]*struct {
+		InstrumentId string `json:"instrument_id"`
+		Ticker
+	}, 0)
+	if err := json.Unmarshal(body, &list); err != nil {
+		resp := &ApiResponse{}
+		if json.Unmarshal(body, resp) == nil && resp.Code != 0 {
+			return nil, errors.New(resp.Message)
+		}
 		return nil, err
 	}
-	if resp.Code != 0 {
-		return nil, errors.New(resp.Message)
-	}
 
-	tick := resp.Ticker
-	if tick == nil {
-		return nil, errors.New("data invalid")
+	ticks := make([]*provider.Tick, 0)
+	for _, item := range list {
+		ticks = append(ticks, &provider.Tick{
+			Symbol:  item.InstrumentId,
+			Last:    item.Last,
+			Vol:     item.Vol,
+			Time:    parseTime(item.Time),
+			Bid:     item.Bid,
+			Ask:     item.Ask,
+			BidSize: item.BidSize,
+			AskSize: item.AskSize,
+		})
 	}
+	return ticks, nil
+}
 
-	return tick, nil
+// ISO8601时间转为毫秒, 格式有误时为0
+func parseTime(val string) int64 {
+	t, err := time.Parse(time.RFC3339Nano, val)
+	if err != nil {
+		return 0
+	}
+	return t.UnixNano() / 1e6
 }
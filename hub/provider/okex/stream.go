@@ -0,0 +1,98 @@
+package okex
+
+import (
+	"bitcoin-kline/constant"
+	"bitcoin-kline/hub/provider"
+	"bytes"
+	"compress/flate"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// websocket文档：https://www.okex.com/docs/zh/#spot_ws-ticker
+// 推送数据经过deflate压缩, 30秒内无数据交互服务端会断开连接, 客户端需定时发送"ping"
+
+const wsUrl = "wss://real.okex.com:8443/ws/v3"
+
+type streamHandler struct{}
+
+type streamTicker struct {
+	InstrumentId string `json:"instrument_id"`
+	*Ticker
+}
+
+type streamResponse struct {
+	Event     string          `json:"event"`
+	Message   string          `json:"message"`
+	ErrorCode int             `json:"errorCode"`
+	Table     string          `json:"table"`
+	Data      []*streamTicker `json:"data"`
+}
+
+func NewStreamProvider() *provider.StreamProvider {
+	return provider.NewStreamProvider(provider.StreamConfig{
+		Name:       constant.ProviderOkex,
+		Origin:     constant.ProviderOkexOriginType,
+		VolumeUnit: provider.VolumeBase,
+		CoinMap:    okCoinMap,
+		Url:        wsUrl,
+		Handler:    &streamHandler{},
+	})
+}
+
+func (h *streamHandler) Url(base string, symbols []string) string {
+	return base
+}
+
+// 订阅ticker频道 spot/ticker:ETH-USDT
+func (h *streamHandler) Subscribe(symbols []string) []string {
+	args := make([]string, 0)
+	for _, symbol := range symbols {
+		args = append(args, "spot/ticker:"+symbol)
+	}
+	msg, _ := json.Marshal(map[string]interface{}{"op": "subscribe", "args": args})
+	return []string{string(msg)}
+}
+
+func (h *streamHandler) Ping() string {
+	return "ping"
+}
+
+func (h *streamHandler) Decode(msg []byte) ([]*provider.Tick, string, error) {
+	body, err := ioutil.ReadAll(flate.NewReader(bytes.NewReader(msg)))
+	if err != nil {
+		return nil, "", err
+	}
+	if strings.TrimSpace(string(body)) == "pong" {
+		return nil, "", nil
+	}
+
+	resp := &streamResponse{}
+	if err := json.Unmarshal(body, resp); err != nil {
+		return nil, "", err
+	}
+	if resp.Event == "error" {
+		return nil, "", errors.New(resp.Message)
+	}
+
+	ticks := make([]*provider.Tick, 0)
+	for _, item := range resp.Data {
+		if item.Ticker == nil {
+			continue
+		}
+		ticks = append(ticks, &provider.Tick{
+			Symbol:  item.InstrumentId,
+			Last:    item.Last,
+			Vol:     item.Vol,
+			Time:    parseTime(item.Time),
+			Bid:     item.Bid,
+			Ask:     item.Ask,
+			BidSize: item.BidSize,
+			AskSize: item.AskSize,
+		})
+	}
+	return ticks, "", nil
+}
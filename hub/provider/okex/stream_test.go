@@ -0,0 +1,107 @@
+package okex
+
+import (
+	"bitcoin-kline/hub/provider"
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"testing"
+)
+
+// 抓取的推送帧, deflate压缩
+const (
+	// {"table":"spot/ticker","data":[{"instrument_id":"ETH-USDT","last":"143.21","best_bid":"143.2","best_ask":"143.22","best_bid_size":"3.5","best_ask_size":"1.2",...,"base_volume_24h":"179000.1","quote_volume_24h":"25632145.12","timestamp":"2019-12-13T04:00:00.123Z"}]}
+	tickerFrame = "XY/BTsMwDIbfJWca7CQF2jNI3CkXEIpSFtFobdMtHgimvTtORAfimM/f7/w+CnL96EUr0hLpksLr1u/Fhdg4cqJ9PoowJ9ofJj+TDRvW7rr76vHhtmNndImYoNFS4c/b7uiTGciaQe8Z9CVWpBW5tF2R+qPZFL5yE/0bZnOlWPJx8bNVZih5LOIQ3oYzMvImN4kfZwKyydtc8vY9jnzJOrluAEDm4rtDpH9TVV9phaaWmH+lMHEbNy15AthUqCrUHZgWoM1LlH4Sp5fTNw=="
+	// {"table":"spot/trade","data":[{"instrument_id":"ETH-USDT","price":"143.21","side":"buy","size":"0.5","timestamp":"2019-12-13T04:00:00.456Z",...}]}
+	tradeFrame = "JY3BCsIwEET/Zc+m7qZpxJ4VvFsvikhqcgi0NSTbg5b+u4nCXN7whlmATT84aCGFF285GutgA9awgfa2gJ8Sx3l0Ez+8zdaxO4nL+dBlJ0T/LENSdSUpF8nbwv38/sGnAFZNBvajS2zGkBuJtBckBdUdqhYxp1KNvhatvP9/SBIqtau1hvW+fgE="
+)
+
+func frame(t *testing.T, val string) []byte {
+	data, err := base64.StdEncoding.DecodeString(val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func deflateFrame(body string) []byte {
+	var buf bytes.Buffer
+	w, _ := flate.NewWriter(&buf, flate.DefaultCompression)
+	_, _ = w.Write([]byte(body))
+	_ = w.Close()
+	return buf.Bytes()
+}
+
+func TestStreamSubscribe(t *testing.T) {
+	msgs := (&streamHandler{}).Subscribe([]string{"ETH-USDT", "BTC-USDT"})
+	if len(msgs) != 1 || msgs[0] != `{"args":["spot/ticker:ETH-USDT","spot/ticker:BTC-USDT"],"op":"subscribe"}` {
+		t.Fatalf("unexpected subscribe: %v", msgs)
+	}
+	msgs = (&tradeHandler{}).Subscribe([]string{"ETH-USDT"})
+	if len(msgs) != 1 || msgs[0] != `{"args":["spot/trade:ETH-USDT"],"op":"subscribe"}` {
+		t.Fatalf("unexpected trade subscribe: %v", msgs)
+	}
+}
+
+func TestStreamDecode(t *testing.T) {
+	ticker := frame(t, tickerFrame)
+	cases := []struct {
+		name  string
+		msg   []byte
+		ticks []provider.Tick
+		err   bool
+	}{
+		{name: "ticker", msg: ticker, ticks: []provider.Tick{{
+			Symbol: "ETH-USDT", Last: "143.21", Vol: "179000.1", Time: 1576209600123,
+			Bid: "143.2", Ask: "143.22", BidSize: "3.5", AskSize: "1.2",
+		}}},
+		{name: "pong", msg: deflateFrame("pong")},
+		{name: "subscribed", msg: deflateFrame(`{"event":"subscribe","channel":"spot/ticker:ETH-USDT"}`)},
+		{name: "api error", msg: deflateFrame(`{"event":"error","message":"Channel spot/ticker:XXX-USDT doesn't exist","errorCode":30040}`), err: true},
+		{name: "truncated", msg: ticker[:len(ticker)/2], err: true},
+		{name: "not deflate", msg: []byte{0xff, 0xff, 0xff, 0xff}, err: true},
+		{name: "invalid json", msg: deflateFrame(`{"table":`), err: true},
+	}
+	h := &streamHandler{}
+	for _, c := range cases {
+		ticks, reply, err := h.Decode(c.msg)
+		if (err != nil) != c.err || reply != "" || len(ticks) != len(c.ticks) {
+			t.Errorf("%s: unexpected result %v %q %v", c.name, ticks, reply, err)
+			continue
+		}
+		for i, tick := range ticks {
+			if *tick != c.ticks[i] {
+				t.Errorf("%s: expect %+v, got %+v", c.name, c.ticks[i], *tick)
+			}
+		}
+	}
+}
+
+func TestTradeDecode(t *testing.T) {
+	trade := frame(t, tradeFrame)
+	cases := []struct {
+		name   string
+		msg    []byte
+		trades []provider.Trade
+		err    bool
+	}{
+		{name: "trade", msg: trade, trades: []provider.Trade{{Symbol: "ETH-USDT", Price: "143.21", Size: "0.5", Side: provider.SideBuy, Time: 1576209600456}}},
+		{name: "pong", msg: deflateFrame("pong")},
+		{name: "api error", msg: deflateFrame(`{"event":"error","message":"Invalid request","errorCode":30039}`), err: true},
+		{name: "truncated", msg: trade[:len(trade)-10], err: true},
+		{name: "not deflate", msg: []byte("pong"), err: true},
+	}
+	h := &tradeHandler{}
+	for _, c := range cases {
+		trades, reply, err := h.Decode(c.msg)
+		if (err != nil) != c.err || reply != "" || len(trades) != len(c.trades) {
+			t.Errorf("%s: unexpected result %v %q %v", c.name, trades, reply, err)
+			continue
+		}
+		for i, item := range trades {
+			if *item != c.trades[i] {
+				t.Errorf("%s: expect %+v, got %+v", c.name, c.trades[i], *item)
+			}
+		}
+	}
+}
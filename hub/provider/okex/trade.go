@@ -0,0 +1,88 @@
+package okex
+
+import (
+	"bitcoin-kline/constant"
+	"bitcoin-kline/hub/provider"
+	"bytes"
+	"compress/flate"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// 逐笔成交 spot/trade:ETH-USDT
+// {"table":"spot/trade","data":[{"instrument_id":"ETH-USDT","price":"162.12","side":"buy","size":"11.085","timestamp":"2019-05-06T06:51:24.389Z","trade_id":"1210447366"}]}
+
+type tradeHandler struct{}
+
+type tradeResponse struct {
+	Event   string `json:"event"`
+	Message string `json:"message"`
+	Table   string `json:"table"`
+	Data    []struct {
+		InstrumentId string `json:"instrument_id"`
+		Price        string `json:"price"`
+		Side         string `json:"side"` // 主动成交方向 buy/sell
+		Size         string `json:"size"` // 以基础币种计量
+		Timestamp    string `json:"timestamp"`
+	} `json:"data"`
+}
+
+func NewTradeProvider() *provider.StreamProvider {
+	return provider.NewStreamProvider(provider.StreamConfig{
+		Name:    constant.ProviderOkex,
+		Origin:  constant.ProviderOkexOriginType,
+		CoinMap: okCoinMap,
+		Url:     wsUrl,
+		Trade:   &tradeHandler{},
+	})
+}
+
+func (h *tradeHandler) Url(base string, symbols []string) string {
+	return base
+}
+
+func (h *tradeHandler) Subscribe(symbols []string) []string {
+	args := make([]string, 0)
+	for _, symbol := range symbols {
+		args = append(args, "spot/trade:"+symbol)
+	}
+	msg, _ := json.Marshal(map[string]interface{}{"op": "subscribe", "args": args})
+	return []string{string(msg)}
+}
+
+func (h *tradeHandler) Ping() string {
+	return "ping"
+}
+
+func (h *tradeHandler) Decode(msg []byte) ([]*provider.Trade, string, error) {
+	body, err := ioutil.ReadAll(flate.NewReader(bytes.NewReader(msg)))
+	if err != nil {
+		return nil, "", err
+	}
+	if strings.TrimSpace(string(body)) == "pong" {
+		return nil, "", nil
+	}
+
+	resp := &tradeResponse{}
+	if err := json.Unmarshal(body, resp); err != nil {
+		return nil, "", err
+	}
+	if resp.Event == "error" {
+		return nil, "", errors.New(resp.Message)
+	}
+
+	trades := make([]*provider.Trade, 0, len(resp.Data))
+	for _, item := range resp.Data {
+		trades = append(trades, &provider.Trade{
+			Symbol: item.InstrumentId,
+			Price:  item.Price,
+			Size:   item.Size,
+			Side:   item.Side,
+			Time:   parseTime(item.Timestamp),
+		})
+	}
+	return trades, "", nil
+}
@@ -0,0 +1,333 @@
+package provider
+
+import (
+	"bitcoin-kline/common"
+	"bitcoin-kline/config"
+	"bitcoin-kline/constant"
+	"bitcoin-kline/logger"
+	"bitcoin-kline/model"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 定时轮询rest接口的数据商
+// 各交易所只需提供交易对映射、请求地址与响应解析
+// 代理、接口域名、超时、重试次数与User-Agent可在配置[provider.<name>]中覆盖
+// 请求经common.HttpClient发出, 相同代理的数据商共用连接池
+// 同一数据商的请求经令牌桶限流, 被交易所限频时整体退避, 失败重试同样经过限流
+// 交易所提供批量接口时每秒只请求一次, 再分发给各币种
+
+const (
+	defaultPollTimeout = 3 * time.Second
+	defaultPollRetries = 1
+)
+
+type PollConfig struct {
+	Name       string            // 数据商名称
+	Origin     int               // 数据来源
+	CoinMap    map[string]string // 币种 -> 交易所交易对
+	BaseUrl    string            // 接口域名, 配置项base_url
+	Timeout    time.Duration     // 请求超时, 默认3秒, 配置项timeout(秒)
+	Retries    int               // 网络错误或网关错误时重试次数, 默认1, 配置项retries
+	Proxy      string            // 代理地址, 配置项proxy
+	UserAgent  string            // 配置项user_agent
+	RateLimit  float64           // 每秒最多请求次数, 默认5, 配置项rate_limit
+	Burst      int               // 突发请求次数, 默认同RateLimit, 配置项burst
+	VolumeUnit string            // 成交量计量单位, VolumeBase(默认)或VolumeQuote
+
+	Url    func(base, symbol string) string // 构造请求地址
+	Decode func(body []byte) (*Tick, error) // 解析响应
+
+	// 批量接口, 配置后每秒只请求一次获取全部交易对, 返回的行情需带上Symbol
+	BatchUrl    func(base string, symbols []string) string
+	BatchDecode func(body []byte) ([]*Tick, error)
+
+	// 不走http请求时自定义获取行情, 如mock数据
+	Fetch func(symbol string) (*Tick, error)
+}
+
+type PollProvider struct {
+	conf     PollConfig
+	readChan map[string]chan *model.Kline
+	limiter  *RateLimiter
+	client   *common.HttpClient
+
+	breakMainLogic chan bool // 结束命令管道
+	sync.WaitGroup
+}
+
+func NewPollProvider(conf PollConfig) *PollProvider {
+	conf.loadConfig()
+	if conf.Timeout == 0 {
+		conf.Timeout = defaultPollTimeout
+	}
+	p := &PollProvider{
+		conf:           conf,
+		readChan:       make(map[string]chan *model.Kline),
+		breakMainLogic: make(chan bool),
+	}
+	if conf.Fetch == nil {
+		p.limiter = NewRateLimiter(conf.RateLimit, conf.Burst)
+		p.client = newHttpClient(conf)
+	}
+
+	for _, coinType := range config.SupportCoinTypes {
+		if _, ok := conf.CoinMap[coinType]; ok {
+			p.readChan[coinType] = make(chan *model.Kline)
+		}
+	}
+
+	return p
+}
+
+// 读取配置[provider.<name>]覆盖默认请求参数
+func (conf *PollConfig) loadConfig() {
+	section := "provider." + conf.Name
+	if val := config.GetConfig(section, "base_url"); val != "" {
+		conf.BaseUrl = val
+	}
+	if val := config.GetConfig(section, "proxy"); val != "" {
+		conf.Proxy = val
+	}
+	if val := config.GetConfig(section, "user_agent"); val != "" {
+		conf.UserAgent = val
+	}
+	if val := config.GetConfigInt(section, "timeout"); val > 0 {
+		conf.Timeout = time.Second * time.Duration(val)
+	}
+	if config.GetConfig(section, "retries") != "" {
+		conf.Retries = config.GetConfigInt(section, "retries")
+	} else if conf.Retries == 0 {
+		conf.Retries = defaultPollRetries
+	}
+	if val := config.GetConfigFloat64(section, "rate_limit"); val > 0 {
+		conf.RateLimit = val
+	}
+	if val := config.GetConfigInt(section, "burst"); val > 0 {
+		conf.Burst = val
+	}
+}
+
+func (p *PollProvider) ReadChan(coinType string) <-chan *model.Kline {
+	return p.readChan[coinType]
+}
+
+func (p *PollProvider) StartCollect() {
+	if p.conf.BatchUrl != nil {
+		p.Add(1)
+		go func() {
+			defer p.Done()
+			p.batchLoop()
+		}()
+		return
+	}
+
+	for coinType := range p.readChan {
+		p.Add(1)
+		go func(c string) {
+			defer p.Done()
+			p.loop(c)
+		}(coinType)
+	}
+}
+
+func (p *PollProvider) coinTypes() []string {
+	coinTypes := make([]string, 0)
+	for coinType := range p.readChan {
+		coinTypes = append(coinTypes, coinType)
+	}
+	sort.Strings(coinTypes)
+	return coinTypes
+}
+
+func (p *PollProvider) Stop() {
+	close(p.breakMainLogic)
+	p.Wait()
+}
+
+func (p *PollProvider) loop(coinType string) {
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			if p.limiter != nil && !p.limiter.Wait(p.breakMainLogic) {
+				return
+			}
+			start := time.Now()
+			tick, err := p.getTicker(coinType)
+			if err != nil {
+				ReportFailure(p.conf.Name, coinType, time.Since(start), err)
+				logger.Error("PollProvider_getTicker", map[string]string{"provider": p.conf.Name, "coinType": coinType}, err.Error())
+				break
+			}
+			ReportSuccess(p.conf.Name, coinType, time.Since(start))
+			p.handleKline(coinType, newKline(coinType, p.conf.Origin, p.conf.VolumeUnit, tick))
+
+		case <-p.breakMainLogic:
+			return
+		}
+	}
+}
+
+// 每秒批量请求一次, 结果分发至各币种的readChan
+func (p *PollProvider) batchLoop() {
+	symbolMap := make(map[string]string) // 交易所交易对 -> 币种
+	for _, coinType := range p.coinTypes() {
+		symbolMap[p.conf.CoinMap[coinType]] = coinType
+	}
+
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			if !p.limiter.Wait(p.breakMainLogic) {
+				return
+			}
+			start := time.Now()
+			ticks, err := p.getTickers()
+			latency := time.Since(start)
+			if err != nil {
+				for coinType := range p.readChan {
+					ReportFailure(p.conf.Name, coinType, latency, err)
+				}
+				logger.Error("PollProvider_getTickers", p.conf.Name, err.Error())
+				break
+			}
+
+			received := make(map[string]*Tick)
+			for _, tick := range ticks {
+				if coinType, ok := symbolMap[tick.Symbol]; ok {
+					received[coinType] = tick
+				}
+			}
+
+			var wg sync.WaitGroup
+			for coinType := range p.readChan {
+				tick, ok := received[coinType]
+				if !ok {
+					ReportFailure(p.conf.Name, coinType, latency, errors.New("symbol not in response"))
+					continue
+				}
+				ReportSuccess(p.conf.Name, coinType, latency)
+
+				wg.Add(1)
+				go func(c string, k *model.Kline) {
+					defer wg.Done()
+					p.handleKline(c, k)
+				}(coinType, newKline(coinType, p.conf.Origin, p.conf.VolumeUnit, tick))
+			}
+			wg.Wait()
+
+		case <-p.breakMainLogic:
+			return
+		}
+	}
+}
+
+func (p *PollProvider) handleKline(coinType string, kline *model.Kline) {
+	select {
+	case p.readChan[coinType] <- kline:
+	case <-time.After(time.Second * constant.ProviderDataExpireTime):
+	case <-p.breakMainLogic:
+	}
+}
+
+// request data here
+func (p *PollProvider) getTicker(coinType string) (*Tick, error) {
+	symbol := p.conf.CoinMap[coinType]
+	if p.conf.Fetch != nil {
+		return p.conf.Fetch(symbol)
+	}
+
+	status, body, err := p.get(p.conf.Url(p.conf.BaseUrl, symbol))
+	if err != nil {
+		return nil, err
+	}
+	tick, err := p.conf.Decode(body)
+	if status >= http.StatusBadRequest {
+		return nil, statusError(status, err)
+	}
+	return tick, err
+}
+
+// 批量获取全部交易对行情
+func (p *PollProvider) getTickers() ([]*Tick, error) {
+	symbols := make([]string, 0)
+	for _, coinType := range p.coinTypes() {
+		symbols = append(symbols, p.conf.CoinMap[coinType])
+	}
+
+	status, body, err := p.get(p.conf.BatchUrl(p.conf.BaseUrl, symbols))
+	if err != nil {
+		return nil, err
+	}
+	ticks, err := p.conf.BatchDecode(body)
+	if status >= http.StatusBadRequest {
+		return nil, statusError(status, err)
+	}
+	return ticks, err
+}
+
+func (p *PollProvider) get(url string) (int, []byte, error) {
+	resp, err := request(p.conf.Name, p.client, p.limiter, p.conf.Retries, url, p.breakMainLogic)
+	if err != nil {
+		return 0, nil, err
+	}
+	return resp.StatusCode, resp.Body, nil
+}
+
+// 发起请求, 网络错误或网关错误(502/503/504)时重试retries次
+// 首次请求前由调用方获取令牌, 每次重试前同样获取令牌, 每次响应均交给限流器调整退避; stop关闭时不再重试
+func request(name string, client *common.HttpClient, limiter *RateLimiter, retries int, url string, stop <-chan bool) (*common.HttpResponse, error) {
+	var (
+		resp *common.HttpResponse
+		err  error
+	)
+	for i := 0; i <= retries; i++ {
+		if i > 0 && !limiter.Wait(stop) {
+			break
+		}
+		resp, err = client.Get(url)
+		if err != nil {
+			continue
+		}
+		if wait := limiter.Observe(resp.StatusCode, resp.Header.Get("Retry-After")); wait > 0 {
+			logger.Error("PollProvider_rateLimit", map[string]interface{}{"provider": name, "status": resp.StatusCode},
+				fmt.Sprintf("request limited, backoff %s", wait.String()))
+		}
+		if !retryStatus(resp.StatusCode) {
+			break
+		}
+	}
+	return resp, err
+}
+
+func retryStatus(status int) bool {
+	return status == http.StatusBadGateway || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout
+}
+
+// 代理地址有误时记录错误并直连
+func newHttpClient(conf PollConfig) *common.HttpClient {
+	client, err := common.NewHttpClient(conf.Proxy, conf.Timeout, conf.UserAgent)
+	if err != nil {
+		logger.Error("PollProvider_newHttpClient", map[string]string{"provider": conf.Name, "proxy": conf.Proxy}, err.Error())
+		client, _ = common.NewHttpClient("", conf.Timeout, conf.UserAgent)
+	}
+	return client
+}
+
+// http错误状态码, 交易所返回的错误信息优先
+func statusError(status int, err error) error {
+	if err != nil {
+		return fmt.Errorf("http status %d: %s", status, err.Error())
+	}
+	return fmt.Errorf("http status %d", status)
+}
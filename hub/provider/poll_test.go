@@ -0,0 +1,177 @@
+package provider_test
+
+import (
+	"bitcoin-kline/constant"
+	"bitcoin-kline/hub/provider"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPollProvider(t *testing.T) {
+	// 第一次请求返回错误, 之后返回正常行情
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			_, _ = fmt.Fprint(w, "error")
+			return
+		}
+		_, _ = fmt.Fprint(w, r.URL.Query().Get("symbol")+",100.5000,10")
+	}))
+	defer server.Close()
+
+	p := provider.NewPollProvider(provider.PollConfig{
+		Name:    constant.ProviderMock,
+		Origin:  constant.ProviderMockOriginType,
+		CoinMap: map[string]string{constant.CoinTypeETHUSDT: "ethusdt"},
+		BaseUrl: server.URL,
+		Url: func(base, symbol string) string {
+			return base + "/ticker?symbol=" + symbol
+		},
+		Decode: func(body []byte) (*provider.Tick, error) {
+			data := strings.Split(string(body), ",")
+			if len(data) != 3 {
+				return nil, errors.New("body data err")
+			}
+			return &provider.Tick{Symbol: data[0], Last: data[1], Vol: data[2]}, nil
+		},
+	})
+	p.StartCollect()
+	defer p.Stop()
+
+	select {
+	case item := <-p.ReadChan(constant.CoinTypeETHUSDT):
+		if item.Close != "100.5000" || item.Volume != "10" || item.Origin != constant.ProviderMockOriginType {
+			t.Fatalf("unexpected kline: %+v", item)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no data from poll provider")
+	}
+
+	if p.ReadChan(constant.CoinTypeBTCUSDT) != nil {
+		t.Fatal("unsupported coin type should not have read chan")
+	}
+}
+
+func TestPollProviderBatch(t *testing.T) {
+	// 批量接口一次返回全部交易对, 其中rucny缺失
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Query().Get("symbols") != "btcusdt,ethusdt,rucny" {
+			t.Errorf("unexpected symbols: %s", r.URL.RawQuery)
+		}
+		_, _ = fmt.Fprint(w, "ethusdt,100.5000,10;btcusdt,9000.2500,20")
+	}))
+	defer server.Close()
+
+	p := provider.NewPollProvider(provider.PollConfig{
+		Name:   constant.ProviderMock,
+		Origin: constant.ProviderMockOriginType,
+		CoinMap: map[string]string{
+			constant.CoinTypeETHUSDT: "ethusdt",
+			constant.CoinTypeBTCUSDT: "btcusdt",
+			constant.CoinTypeRUCNY:   "rucny",
+		},
+		BaseUrl: server.URL,
+		BatchUrl: func(base string, symbols []string) string {
+			return base + "/tickers?symbols=" + strings.Join(symbols, ",")
+		},
+		BatchDecode: func(body []byte) ([]*provider.Tick, error) {
+			ticks := make([]*provider.Tick, 0)
+			for _, item := range strings.Split(string(body), ";") {
+				data := strings.Split(item, ",")
+				if len(data) != 3 {
+					return nil, errors.New("body data err")
+				}
+				ticks = append(ticks, &provider.Tick{Symbol: data[0], Last: data[1], Vol: data[2]})
+			}
+			return ticks, nil
+		},
+	})
+	p.StartCollect()
+	defer p.Stop()
+
+	expect := map[string]string{
+		constant.CoinTypeETHUSDT: "100.5000",
+		constant.CoinTypeBTCUSDT: "9000.2500",
+	}
+	for coinType, price := range expect {
+		select {
+		case item := <-p.ReadChan(coinType):
+			if item.CoinType != coinType || item.Close != price {
+				t.Fatalf("unexpected kline: %+v", item)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no data for %s", coinType)
+		}
+	}
+
+	select {
+	case item := <-p.ReadChan(constant.CoinTypeRUCNY):
+		t.Fatalf("unexpected kline for missing symbol: %+v", item)
+	case <-time.After(1500 * time.Millisecond):
+	}
+
+	// 各币种共用一次请求
+	if n := atomic.LoadInt32(&requests); n > 3 {
+		t.Fatalf("too many batch requests: %d", n)
+	}
+}
+
+func TestPollProviderRetry(t *testing.T) {
+	// 第一次返回503, 重试前按限流退避
+	var (
+		requests int32
+		times    = make(chan time.Time, 10)
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		times <- time.Now()
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = fmt.Fprint(w, "ethusdt,100.5000,10")
+	}))
+	defer server.Close()
+
+	p := provider.NewPollProvider(provider.PollConfig{
+		Name:    constant.ProviderMock,
+		Origin:  constant.ProviderMockOriginType,
+		CoinMap: map[string]string{constant.CoinTypeETHUSDT: "ethusdt"},
+		BaseUrl: server.URL,
+		Retries: 1,
+		Url: func(base, symbol string) string {
+			return base + "/ticker?symbol=" + symbol
+		},
+		Decode: func(body []byte) (*provider.Tick, error) {
+			data := strings.Split(string(body), ",")
+			if len(data) != 3 {
+				return nil, errors.New("body data err")
+			}
+			return &provider.Tick{Symbol: data[0], Last: data[1], Vol: data[2]}, nil
+		},
+	})
+	p.StartCollect()
+	defer p.Stop()
+
+	select {
+	case item := <-p.ReadChan(constant.CoinTypeETHUSDT):
+		if item.Close != "100.5000" {
+			t.Fatalf("unexpected kline: %+v", item)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no data after retry")
+	}
+
+	first, second := <-times, <-times
+	if wait := second.Sub(first); wait < 900*time.Millisecond {
+		t.Fatalf("retry should wait for backoff, waited %s", wait)
+	}
+}
@@ -1,7 +1,17 @@
 package provider
 
 import (
+	"bitcoin-kline/config"
 	"bitcoin-kline/model"
+	"time"
+)
+
+// 数据商采集模式, 配置在[provider.<name>]的mode项
+const (
+	ModeRest   = "rest"   // 定时轮询rest接口(默认)
+	ModeStream = "ws"     // 订阅websocket推送
+	ModeTrade  = "trade"  // 订阅websocket逐笔成交, 按秒生成OHLCV k线
+	ModeReplay = "replay" // 回放录制的数据
 )
 
 type Provider interface {
@@ -9,3 +19,60 @@ type Provider interface {
 	StartCollect()
 	Stop()
 }
+
+// 交易所返回的行情
+type Tick struct {
+	Symbol string // 交易所交易对
+	Last   string // 最新成交价
+	Vol    string // 24小时成交量, 计量单位见数据商声明的VolumeUnit
+	High   string // 24小时最高价, 可为空
+	Low    string // 24小时最低价, 可为空
+	Time   int64  // 交易所行情时间 毫秒, 可为0
+
+	// 买一卖一价格与数量, 交易所未提供时为空
+	Bid     string
+	Ask     string
+	BidSize string
+	AskSize string
+}
+
+// 数据商的采集模式
+func Mode(name string) string {
+	mode := config.GetConfig("provider."+name, "mode")
+	if mode == "" {
+		return ModeRest
+	}
+	return mode
+}
+
+// 将交易所行情转换为秒级kline, CreateTime为本地时间, 交易所时间另存于ExchangeTime
+// Volume保留交易所原始成交量及其计量单位VolumeUnit, Volume24h、QuoteVolume24h为换算后的基础币种与报价币种成交量
+func newKline(coinType string, origin int, unit string, tick *Tick) *model.Kline {
+	received := time.Now()
+	now := received.Unix()
+	base, quote := NormalizeVolume(tick.Vol, tick.Last, unit)
+	return &model.Kline{
+		CoinType:    coinType,
+		High:        tick.Last,
+		Low:         tick.Last,
+		Open:        tick.Last,
+		Close:       tick.Last,
+		CreateTime:  now,
+		UpdateTime:  now,
+		TimeScale:   "1s",
+		Origin:      origin,
+		OriginPrice: "",
+		Volume:      tick.Vol,
+
+		ExchangeTime: tick.Time,
+		ReceiveTime:  received.UnixNano() / 1e6,
+		Bid:          tick.Bid,
+		Ask:          tick.Ask,
+		BidSize:      tick.BidSize,
+		AskSize:      tick.AskSize,
+
+		Volume24h:      base,
+		QuoteVolume24h: quote,
+		VolumeUnit:     unit,
+	}
+}
@@ -0,0 +1,130 @@
+package provider
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 令牌桶限流, 同一数据商的所有请求共用
+// 交易所返回429(请求过多)、418(ip被封禁)或5xx时暂停请求并指数退避, 优先使用响应头Retry-After
+
+const (
+	defaultRateLimit  = 5 // 未配置时每秒最多请求次数
+	minBackoff        = time.Second
+	maxBackoff        = 5 * time.Minute
+	rateLimitStatusIP = 418
+)
+
+type RateLimiter struct {
+	rate   float64 // 每秒生成令牌数
+	burst  float64 // 令牌桶容量
+	tokens float64
+	last   time.Time
+
+	backoff time.Duration // 当前退避时长
+	until   time.Time     // 退避截止时间
+
+	sync.Mutex
+}
+
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if rate <= 0 {
+		rate = defaultRateLimit
+	}
+	if burst <= 0 {
+		burst = int(rate)
+		if burst < 1 {
+			burst = 1
+		}
+	}
+	return &RateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// 阻塞直到获取令牌, stop关闭时返回false
+func (l *RateLimiter) Wait(stop <-chan bool) bool {
+	for {
+		wait := l.reserve()
+		if wait <= 0 {
+			return true
+		}
+		select {
+		case <-time.After(wait):
+		case <-stop:
+			return false
+		}
+	}
+}
+
+// 尝试获取令牌, 返回需要等待的时间
+func (l *RateLimiter) reserve() time.Duration {
+	l.Lock()
+	defer l.Unlock()
+
+	now := time.Now()
+	if now.Before(l.until) {
+		return l.until.Sub(now)
+	}
+
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
+
+// 根据响应状态码调整退避, 返回本次退避时长, 0表示无需退避
+func (l *RateLimiter) Observe(status int, retryAfter string) time.Duration {
+	l.Lock()
+	defer l.Unlock()
+
+	if status != http.StatusTooManyRequests && status != rateLimitStatusIP && status < http.StatusInternalServerError {
+		if status < http.StatusBadRequest {
+			l.backoff = 0
+		}
+		return 0
+	}
+
+	if l.backoff == 0 {
+		l.backoff = minBackoff
+	} else {
+		l.backoff *= 2
+	}
+	if l.backoff > maxBackoff {
+		l.backoff = maxBackoff
+	}
+	wait := l.backoff
+	if d := parseRetryAfter(retryAfter); d > wait {
+		wait = d
+	}
+
+	if until := time.Now().Add(wait); until.After(l.until) {
+		l.until = until
+	}
+	return wait
+}
+
+// Retry-After为秒数或http时间
+func parseRetryAfter(val string) time.Duration {
+	if val == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(val); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(val); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
@@ -0,0 +1,52 @@
+package provider_test
+
+import (
+	"bitcoin-kline/hub/provider"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	stop := make(chan bool)
+	l := provider.NewRateLimiter(10, 2)
+
+	// 令牌桶容量内不等待, 之后按速率放行
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if !l.Wait(stop) {
+			t.Fatal("wait should succeed")
+		}
+	}
+	if cost := time.Since(start); cost < 150*time.Millisecond || cost > time.Second {
+		t.Fatalf("unexpected wait time: %s", cost)
+	}
+
+	// 非限频状态码不退避
+	if wait := l.Observe(http.StatusOK, ""); wait != 0 {
+		t.Fatalf("unexpected backoff: %s", wait)
+	}
+	if wait := l.Observe(http.StatusNotFound, ""); wait != 0 {
+		t.Fatalf("unexpected backoff: %s", wait)
+	}
+
+	// 429指数退避, Retry-After优先
+	if wait := l.Observe(http.StatusTooManyRequests, ""); wait != time.Second {
+		t.Fatalf("unexpected backoff: %s", wait)
+	}
+	if wait := l.Observe(http.StatusServiceUnavailable, ""); wait != 2*time.Second {
+		t.Fatalf("unexpected backoff: %s", wait)
+	}
+	if wait := l.Observe(418, "30"); wait != 30*time.Second {
+		t.Fatalf("unexpected backoff: %s", wait)
+	}
+
+	// 退避期间Wait阻塞, 结束时返回false
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		close(stop)
+	}()
+	if l.Wait(stop) {
+		t.Fatal("wait should be interrupted during backoff")
+	}
+}
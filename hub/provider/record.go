@@ -0,0 +1,118 @@
+package provider
+
+import (
+	"bitcoin-kline/config"
+	"bitcoin-kline/constant"
+	"bitcoin-kline/logger"
+	"bitcoin-kline/model"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// 录制数据商产出的kline, 每行一条json, 供replay模式回放
+// 配置[provider]的record_dir后, 所有数据商的数据写入<record_dir>/<name>.jsonl
+
+type Recorder struct {
+	name     string
+	inner    Provider
+	file     *os.File
+	readChan map[string]chan *model.Kline
+
+	breakMainLogic chan bool // 结束命令管道
+	sync.Mutex               // 保护文件写入
+	sync.WaitGroup
+}
+
+// 录制目录, 为空则不录制
+func recordDir() string {
+	return config.GetConfig("provider", "record_dir")
+}
+
+// 录制文件路径
+func RecordFile(dir, name string) string {
+	return filepath.Join(dir, name+".jsonl")
+}
+
+func NewRecorder(name string, inner Provider, dir string) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(RecordFile(dir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Recorder{
+		name:           name,
+		inner:          inner,
+		file:           file,
+		readChan:       make(map[string]chan *model.Kline),
+		breakMainLogic: make(chan bool),
+	}
+	for _, coinType := range config.SupportCoinTypes {
+		if inner.ReadChan(coinType) != nil {
+			r.readChan[coinType] = make(chan *model.Kline)
+		}
+	}
+	return r, nil
+}
+
+func (r *Recorder) ReadChan(coinType string) <-chan *model.Kline {
+	return r.readChan[coinType]
+}
+
+func (r *Recorder) StartCollect() {
+	r.inner.StartCollect()
+	for coinType := range r.readChan {
+		r.Add(1)
+		go func(c string) {
+			defer r.Done()
+			r.loop(c)
+		}(coinType)
+	}
+}
+
+func (r *Recorder) Stop() {
+	r.inner.Stop()
+	close(r.breakMainLogic)
+	r.Wait()
+	if err := r.file.Close(); err != nil {
+		logger.Error("Recorder_close", r.name, err.Error())
+	}
+}
+
+// 写入文件后原样转发
+func (r *Recorder) loop(coinType string) {
+	for {
+		select {
+		case kline := <-r.inner.ReadChan(coinType):
+			r.write(kline)
+			select {
+			case r.readChan[coinType] <- kline:
+			case <-time.After(time.Second * constant.ProviderDataExpireTime):
+			case <-r.breakMainLogic:
+				return
+			}
+
+		case <-r.breakMainLogic:
+			return
+		}
+	}
+}
+
+func (r *Recorder) write(kline *model.Kline) {
+	data, err := json.Marshal(kline)
+	if err != nil {
+		logger.Error("Recorder_marshal", kline, err.Error())
+		return
+	}
+
+	r.Lock()
+	defer r.Unlock()
+	if _, err := r.file.Write(append(data, '\n')); err != nil {
+		logger.Error("Recorder_write", r.name, err.Error())
+	}
+}
@@ -0,0 +1,151 @@
+package provider
+
+import (
+	"bitcoin-kline/config"
+	"bitcoin-kline/constant"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// 数据商注册表
+// 各数据商包在init中按名称注册构造函数, 由配置决定启用哪些数据商
+// 按类型注册的数据商可启用多个实例, 实例配置[provider.<name>]的type项指定类型
+
+type Factory func() Provider
+
+// 按实例名称创建数据商, 实例配置有误时返回错误
+type TypeFactory func(name string) (Provider, error)
+
+var (
+	factories       = make(map[string]Factory)     // rest轮询模式
+	streamFactories = make(map[string]Factory)     // websocket推送模式
+	tradeFactories  = make(map[string]Factory)     // 逐笔成交模式
+	typeFactories   = make(map[string]TypeFactory) // 类型 -> 构造函数
+
+	originLock sync.RWMutex
+)
+
+// 注册轮询模式数据商, 重复注册会panic
+func Register(name string, f Factory) {
+	if _, ok := factories[name]; ok {
+		panic("provider " + name + " registered twice")
+	}
+	factories[name] = f
+}
+
+// 注册websocket推送模式数据商
+func RegisterStream(name string, f Factory) {
+	if _, ok := streamFactories[name]; ok {
+		panic("stream provider " + name + " registered twice")
+	}
+	streamFactories[name] = f
+}
+
+// 注册逐笔成交模式数据商
+func RegisterTrade(name string, f Factory) {
+	if _, ok := tradeFactories[name]; ok {
+		panic("trade provider " + name + " registered twice")
+	}
+	tradeFactories[name] = f
+}
+
+// 注册数据商类型
+func RegisterType(typ string, f TypeFactory) {
+	if _, ok := typeFactories[typ]; ok {
+		panic("provider type " + typ + " registered twice")
+	}
+	typeFactories[typ] = f
+}
+
+// 按配置的采集模式创建数据商, 配置了record_dir时录制产出的数据
+func New(name string) (Provider, error) {
+	mode := Mode(name)
+	if mode == ModeReplay {
+		return NewReplayProvider(name)
+	}
+
+	p, err := newProvider(name, mode)
+	if err != nil {
+		return nil, err
+	}
+	if dir := recordDir(); dir != "" {
+		return NewRecorder(name, p, dir)
+	}
+	return p, nil
+}
+
+func newProvider(name, mode string) (Provider, error) {
+	if typ := config.GetConfig("provider."+name, "type"); typ != "" {
+		if f, ok := typeFactories[typ]; ok {
+			return f(name)
+		}
+		return nil, errors.New("provider " + name + " type " + typ + " not registered")
+	}
+
+	switch mode {
+	case ModeRest:
+		if f, ok := factories[name]; ok {
+			return f(), nil
+		}
+	case ModeStream:
+		if f, ok := streamFactories[name]; ok {
+			return f(), nil
+		}
+	case ModeTrade:
+		if f, ok := tradeFactories[name]; ok {
+			return f(), nil
+		}
+	default:
+		return nil, errors.New("provider " + name + " mode " + mode + " not support")
+	}
+
+	return nil, errors.New("provider " + name + " not registered in mode " + mode)
+}
+
+// 登记配置的数据来源, 与已有来源冲突时返回错误
+// 运行时添加数据商会登记来源, 读写constant.ProviderOriginMap均需经过originLock
+func RegisterOrigin(origin int, name string) error {
+	originLock.Lock()
+	defer originLock.Unlock()
+	if val, ok := constant.ProviderOriginMap[origin]; ok && val != name {
+		return fmt.Errorf("provider %s origin %d already used by %s", name, origin, val)
+	}
+	constant.ProviderOriginMap[origin] = name
+	return nil
+}
+
+// 数据来源对应的数据商名称
+func OriginName(origin int) string {
+	originLock.RLock()
+	defer originLock.RUnlock()
+	return constant.ProviderOriginMap[origin]
+}
+
+// 数据商名称对应的数据来源
+func NameOrigin(name string) (int, bool) {
+	originLock.RLock()
+	defer originLock.RUnlock()
+	for origin, val := range constant.ProviderOriginMap {
+		if val == name {
+			return origin, true
+		}
+	}
+	return 0, false
+}
+
+// 当前环境启用的数据商, 配置在[provider]的enabled项, 逗号分隔
+func Enabled() []string {
+	names := make([]string, 0)
+	exist := make(map[string]bool)
+	for _, name := range strings.Split(config.GetConfig("provider", "enabled"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || exist[name] {
+			continue
+		}
+		exist[name] = true
+		names = append(names, name)
+	}
+	return names
+}
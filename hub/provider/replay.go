@@ -0,0 +1,149 @@
+package provider
+
+import (
+	"bitcoin-kline/config"
+	"bitcoin-kline/constant"
+	"bitcoin-kline/logger"
+	"bitcoin-kline/model"
+	"bufio"
+	"encoding/json"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 回放录制的kline, 用于本地复现线上问题
+// 配置[provider.<name>]的mode = replay启用, 可选项:
+// replay_file: 录制文件, 默认<record_dir>/<name>.jsonl
+// speed: 回放倍速, 默认1即按录制时的间隔推送
+// 推送时的时间改为当前时间, 价格、成交量与来源保持录制时的数据
+
+const defaultReplaySpeed = 1
+
+type ReplayProvider struct {
+	name     string
+	speed    float64
+	klines   map[string][]*model.Kline // 币种 -> 按时间排序的kline
+	start    int64                     // 录制的起始时间
+	readChan map[string]chan *model.Kline
+
+	breakMainLogic chan bool // 结束命令管道
+	sync.WaitGroup
+}
+
+func NewReplayProvider(name string) (*ReplayProvider, error) {
+	section := "provider." + name
+	file := config.GetConfig(section, "replay_file")
+	if file == "" {
+		file = RecordFile(recordDir(), name)
+	}
+	return NewReplayProviderFromFile(name, file, config.GetConfigFloat64(section, "speed"))
+}
+
+// 指定录制文件与倍速创建回放数据商
+func NewReplayProviderFromFile(name, file string, speed float64) (*ReplayProvider, error) {
+	if speed <= 0 {
+		speed = defaultReplaySpeed
+	}
+	klines, err := loadRecord(file)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &ReplayProvider{
+		name:           name,
+		speed:          speed,
+		klines:         make(map[string][]*model.Kline),
+		readChan:       make(map[string]chan *model.Kline),
+		breakMainLogic: make(chan bool),
+	}
+	for _, kline := range klines {
+		if p.start == 0 || kline.CreateTime < p.start {
+			p.start = kline.CreateTime
+		}
+		p.klines[kline.CoinType] = append(p.klines[kline.CoinType], kline)
+	}
+	for _, coinType := range config.SupportCoinTypes {
+		if list, ok := p.klines[coinType]; ok {
+			sort.SliceStable(list, func(i, j int) bool {
+				return list[i].CreateTime < list[j].CreateTime
+			})
+			p.readChan[coinType] = make(chan *model.Kline)
+		}
+	}
+	return p, nil
+}
+
+// 读取录制文件, 每行一条kline
+func loadRecord(file string) ([]*model.Kline, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	klines := make([]*model.Kline, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		kline := &model.Kline{}
+		if err := json.Unmarshal(scanner.Bytes(), kline); err != nil {
+			return nil, err
+		}
+		klines = append(klines, kline)
+	}
+	return klines, scanner.Err()
+}
+
+func (p *ReplayProvider) ReadChan(coinType string) <-chan *model.Kline {
+	return p.readChan[coinType]
+}
+
+func (p *ReplayProvider) StartCollect() {
+	start := time.Now()
+	for coinType := range p.readChan {
+		p.Add(1)
+		go func(c string) {
+			defer p.Done()
+			p.loop(c, start)
+		}(coinType)
+	}
+}
+
+func (p *ReplayProvider) Stop() {
+	close(p.breakMainLogic)
+	p.Wait()
+}
+
+// 按录制时的间隔推送, 全部推送完后结束
+func (p *ReplayProvider) loop(coinType string, start time.Time) {
+	for _, item := range p.klines[coinType] {
+		offset := time.Duration(float64(item.CreateTime-p.start) * float64(time.Second) / p.speed)
+		select {
+		case <-time.After(time.Until(start.Add(offset))):
+		case <-p.breakMainLogic:
+			return
+		}
+
+		kline := item.Copy()
+		now := time.Now()
+		kline.CreateTime = now.Unix()
+		kline.UpdateTime = kline.CreateTime
+		kline.TimeScale = "1s"
+		// 保留录制时交易所时间与本地时间的偏差
+		kline.ReceiveTime = now.UnixNano() / 1e6
+		if item.ExchangeTime > 0 && item.ReceiveTime > 0 {
+			kline.ExchangeTime = kline.ReceiveTime - (item.ReceiveTime - item.ExchangeTime)
+		}
+		select {
+		case p.readChan[coinType] <- &kline:
+		case <-time.After(time.Second * constant.ProviderDataExpireTime):
+		case <-p.breakMainLogic:
+			return
+		}
+	}
+	logger.Info("ReplayProvider_finish", map[string]string{"provider": p.name, "coinType": coinType}, "replay finished")
+}
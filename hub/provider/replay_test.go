@@ -0,0 +1,72 @@
+package provider_test
+
+import (
+	"bitcoin-kline/constant"
+	"bitcoin-kline/hub/provider"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRecordReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kline-record")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// 录制3条递增的行情
+	price := 100
+	inner := provider.NewPollProvider(provider.PollConfig{
+		Name:    constant.ProviderMock,
+		Origin:  constant.ProviderMockOriginType,
+		CoinMap: map[string]string{constant.CoinTypeETHUSDT: "ethusdt"},
+		Fetch: func(symbol string) (*provider.Tick, error) {
+			price++
+			return &provider.Tick{Symbol: symbol, Last: strconv.Itoa(price), Vol: "1"}, nil
+		},
+	})
+	recorder, err := provider.NewRecorder(constant.ProviderMock, inner, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder.StartCollect()
+	recorded := make([]string, 0)
+	for len(recorded) < 3 {
+		select {
+		case item := <-recorder.ReadChan(constant.CoinTypeETHUSDT):
+			recorded = append(recorded, item.Close)
+		case <-time.After(5 * time.Second):
+			t.Fatal("no data from recorder")
+		}
+	}
+	recorder.Stop()
+
+	// 10倍速回放, 数据与录制时一致
+	p, err := provider.NewReplayProviderFromFile(constant.ProviderMock, provider.RecordFile(dir, constant.ProviderMock), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ReadChan(constant.CoinTypeBTCUSDT) != nil {
+		t.Fatal("coin type not recorded should not have read chan")
+	}
+	p.StartCollect()
+	defer p.Stop()
+
+	start := time.Now()
+	for i, expect := range recorded {
+		select {
+		case item := <-p.ReadChan(constant.CoinTypeETHUSDT):
+			if item.Close != expect || item.Origin != constant.ProviderMockOriginType {
+				t.Fatalf("replay %d: expect close %s, got %+v", i, expect, item)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no replay data at %d", i)
+		}
+	}
+	if time.Since(start) > time.Second {
+		t.Fatalf("replay too slow: %s", time.Since(start))
+	}
+}
@@ -0,0 +1,96 @@
+package sina
+
+import (
+	"bitcoin-kline/logger"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 连续合约换月检测
+// 连续合约(如nf_RU0)跟随主力合约, 主力合约切换时价格会跳空
+// 同一合约新交易日的昨结算应等于上一交易日的结算价, 两者偏离超过阈值即视为换月
+
+const (
+	rolloverThreshold = 0.005 // 昨结算与上一交易日结算价的偏离阈值
+	maxRollovers      = 100   // 保留最近的换月记录数
+)
+
+type Rollover struct {
+	Symbol     string  `json:"symbol"`
+	Date       string  `json:"date"`       // 换月后的交易日
+	PrevDate   string  `json:"prevDate"`   // 换月前的交易日
+	PrevSettle string  `json:"prevSettle"` // 换月前合约的结算价
+	PreSettle  string  `json:"preSettle"`  // 换月后合约的昨结算
+	Gap        float64 `json:"gap"`        // 价差比例
+	DetectTime int64   `json:"detectTime"`
+}
+
+type contractState struct {
+	date   string
+	settle string
+}
+
+var (
+	contractStates = make(map[string]*contractState) // 合约代码 -> 最近交易日及结算价
+	rollovers      = make([]Rollover, 0)
+	rolloverLock   sync.RWMutex
+)
+
+// 交易日变化时比较昨结算与上一交易日结算价
+func checkRollover(quote *Quote) *Rollover {
+	rolloverLock.Lock()
+	defer rolloverLock.Unlock()
+
+	state, ok := contractStates[quote.Symbol]
+	if !ok {
+		contractStates[quote.Symbol] = &contractState{date: quote.Date, settle: quote.Settle}
+		return nil
+	}
+	if state.date == quote.Date {
+		if validPrice(quote.Settle) {
+			state.settle = quote.Settle
+		}
+		return nil
+	}
+
+	var rollover *Rollover
+	prevSettle, _ := strconv.ParseFloat(state.settle, 64)
+	preSettle, _ := strconv.ParseFloat(quote.PreSettle, 64)
+	if prevSettle > 0 && preSettle > 0 {
+		gap := (preSettle - prevSettle) / prevSettle
+		if math.Abs(gap) > rolloverThreshold {
+			rollover = &Rollover{
+				Symbol:     quote.Symbol,
+				Date:       quote.Date,
+				PrevDate:   state.date,
+				PrevSettle: state.settle,
+				PreSettle:  quote.PreSettle,
+				Gap:        gap,
+				DetectTime: time.Now().Unix(),
+			}
+			rollovers = append(rollovers, *rollover)
+			if len(rollovers) > maxRollovers {
+				rollovers = rollovers[len(rollovers)-maxRollovers:]
+			}
+			logger.Error("SinaProvider_rollover", rollover, "continuous contract rolled over, price series has a gap")
+		}
+	}
+
+	state.date = quote.Date
+	state.settle = quote.Settle
+	return rollover
+}
+
+func validPrice(val string) bool {
+	n, err := strconv.ParseFloat(val, 64)
+	return err == nil && n > 0
+}
+
+// 检测到的换月记录, 按时间正序
+func Rollovers() []Rollover {
+	rolloverLock.RLock()
+	defer rolloverLock.RUnlock()
+	return append([]Rollover{}, rollovers...)
+}
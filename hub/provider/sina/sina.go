@@ -3,137 +3,153 @@ package sina
 import (
 	"bitcoin-kline/config"
 	"bitcoin-kline/constant"
-	"bitcoin-kline/model"
+	"bitcoin-kline/hub/provider"
 	"errors"
 	"strings"
-	"sync"
 	"time"
-
-	"github.com/parnurzeal/gorequest"
 )
 
-// 橡胶期货
+// 新浪期货行情, 支持橡胶、沪铜、豆粕等商品期货
 // 官网：https://finance.sina.com.cn/futures/quotes/RU0.shtml
 // Api文档：http://joeychou.me/blog/53.html
-// ticker接口：https://www.zb.com/api#pmtjkvevzyqinkb
-
-type Provider struct {
-	readChan map[string]chan *model.Kline
-
-	breakMainLogic chan bool // 结束命令管道
-	sync.WaitGroup
-}
+// 交易对为新浪合约代码, nf_RU0为橡胶连续合约, nf_RU2005为具体月份合约
+// 合约列表可在[provider.sina]的contracts项配置, 如 RU/CNY:nf_RU0,CU/CNY:nf_CU0
+// 全部合约一次请求, 连续合约换月见rollover.go
 
-type Ticker struct {
-	High string `json:"high"` // 最高价
-	Open string `json:"high"` // 开盘价
-	Low  string `json:"low"`  // 最低价
-	Last string `json:"last"` // 最新成交价
-	Sell string `json:"sell"` // 卖一价
-	Buy  string `json:"buy"`  // 买一价
-	Vol  string `json:"vol"`  // 成交量(最近的24小时)
-}
+const baseUrl = "http://hq.sinajs.cn"
 
-type ApiResponse struct {
-	Date   string  `json:"date"`
-	Ticker *Ticker `json:"ticker"`
-	Error  string  `json:"error"`
-}
+// 行情时间为北京时间
+var location = time.FixedZone("CST", 8*3600)
 
 var (
+	errNoData = errors.New("contract has no data")
+
 	coinMap = map[string]string{
 		constant.CoinTypeRUCNY: "nf_RU0",
+		constant.CoinTypeCUCNY: "nf_CU0",
+		constant.CoinTypeMCNY:  "nf_M0",
 	}
 )
 
-func NewProvider() *Provider {
-	p := &Provider{
-		readChan:       make(map[string]chan *model.Kline),
-		breakMainLogic: make(chan bool),
-	}
-
-	for _, coinTyp := range config.SupportCoinTypes {
-		p.readChan[coinTyp] = make(chan *model.Kline)
-	}
-
-	return p
+// 期货行情, 对应新浪返回的前18项
+type Quote struct {
+	Symbol       string // 新浪合约代码
+	Name         string // 0：名字
+	Time         string // 1：行情时间 hhmmss
+	Open         string // 2：开盘价
+	High         string // 3：最高价
+	Low          string // 4：最低价
+	PreClose     string // 5：昨日收盘价
+	Bid          string // 6：买价，即“买一”报价
+	Ask          string // 7：卖价，即“卖一”报价
+	Last         string // 8：最新价，即收盘价
+	Settle       string // 9：结算价
+	PreSettle    string // 10：昨结算
+	BidVol       string // 11：买 量
+	AskVol       string // 12：卖 量
+	OpenInterest string // 13：持仓量
+	Volume       string // 14：成交量
+	Exchange     string // 15：交易所简称
+	Variety      string // 16：品种名简称
+	Date         string // 17：日期
 }
 
-func (p *Provider) ReadChan(coinType string) <-chan *model.Kline {
-	return p.readChan[coinType]
+func init() {
+	provider.Register(constant.ProviderSina, func() provider.Provider { return NewProvider() })
 }
 
-func (p *Provider) StartCollect() {
-	for _, coinType := range config.SupportCoinTypes {
-		// 不支持的交易对跳过
-		if _, ok := coinMap[coinType]; ok {
-			p.Add(1)
-			go func(c string) {
-				defer p.Done()
-				p.loop(c)
-			}(coinType)
-		}
+func NewProvider() *provider.PollProvider {
+	contracts := coinMap
+	if val := config.GetConfig("provider."+constant.ProviderSina, "contracts"); val != "" {
+		contracts = parseContracts(val)
 	}
+
+	return provider.NewPollProvider(provider.PollConfig{
+		Name:        constant.ProviderSina,
+		Origin:      constant.ProviderSinaOriginType,
+		VolumeUnit:  provider.VolumeBase,
+		CoinMap:     contracts,
+		UserAgent:   "Chrome/39.0.2171.71",
+		BaseUrl:     baseUrl,
+		Url:         tickerUrl,
+		Decode:      decodeTicker,
+		BatchUrl:    tickersUrl,
+		BatchDecode: decodeTickers,
+	})
 }
 
-func (p *Provider) loop(params ...interface{}) {
-	if len(params) <= 0 {
-		return
-	}
-	coinType := params[0].(string)
-	t := time.NewTicker(time.Second)
-	defer t.Stop()
-
-	for {
-		select {
-		case <-t.C:
-			kline, err := p.getTicker(coinType)
-			if err != nil || kline == nil {
-				//				logger.Error("SinaProvider_getTicker", nil, err.Error())
-				break
-			}
-			now := time.Now().Unix()
-
-			item := &model.Kline{
-				CoinType:    coinType,
-				High:        kline.Last,
-				Low:         kline.Last,
-				Open:        kline.Last,
-				Close:       kline.Last,
-				CreateTime:  now,
-				UpdateTime:  now,
-				TimeScale:   "1s",
-				Origin:      constant.ProviderSinaOriginType,
-				OriginPrice: "",
-				Volume:      kline.Vol,
-			}
-
-			p.handleKline(coinType, item)
-			break
-		case <-p.breakMainLogic:
-			return
+// 币种:合约代码, 逗号分隔
+func parseContracts(val string) map[string]string {
+	contracts := make(map[string]string)
+	for _, item := range strings.Split(val, ",") {
+		i := strings.LastIndex(item, ":")
+		if i <= 0 {
+			continue
 		}
+		contracts[strings.TrimSpace(item[:i])] = strings.TrimSpace(item[i+1:])
 	}
+	return contracts
 }
 
-func (p *Provider) handleKline(coinType string, kline *model.Kline) {
-	select {
-	case p.readChan[coinType] <- kline:
+// 获取最新价
+func tickerUrl(base, symbol string) string {
+	return base + "/list=" + symbol
+}
 
-	case <-time.After(time.Second * constant.ProviderDataExpireTime):
-	case <-p.breakMainLogic:
+// 多个合约以逗号分隔, 每个合约返回一行
+func tickersUrl(base string, symbols []string) string {
+	return base + "/list=" + strings.Join(symbols, ",")
+}
 
+func decodeTicker(body []byte) (*provider.Tick, error) {
+	ticks, err := decodeTickers(body)
+	if err != nil {
+		return nil, err
 	}
+	if len(ticks) == 0 {
+		return nil, errors.New("body data err")
+	}
+	return ticks[0], nil
 }
 
-func (p *Provider) Stop() {
-	close(p.breakMainLogic)
-	p.Wait()
+// 数据返回为字符串, 每个合约一行：var hq_str_RU0="橡胶连续,225956,13145.00,13185.00,13005.00,13120.00,13115.00,13120.00,13120.00,13107.00,13265.00,1,6,434190,272890,沪,橡胶,2019-12-13,0,13455.000,13055.000,13455.000,12535.000,13455.000,11870.000,13455.000,11330.000,250.266";
+// 合约不存在时返回空字符串：var hq_str_nf_XX0="";
+func decodeTickers(body []byte) ([]*provider.Tick, error) {
+	ticks := make([]*provider.Tick, 0)
+	for _, line := range strings.Split(string(body), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		quote, err := ParseQuote(line)
+		if err == errNoData {
+			// 由轮询按缺失交易对处理
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		checkRollover(quote)
+
+		ticks = append(ticks, &provider.Tick{
+			Symbol:  quote.Symbol,
+			Last:    quote.Last,
+			Vol:     quote.Volume,
+			High:    quote.High,
+			Low:     quote.Low,
+			Time:    quote.Timestamp(),
+			Bid:     quote.Bid,
+			Ask:     quote.Ask,
+			BidSize: quote.BidVol,
+			AskSize: quote.AskVol,
+		})
+	}
+	return ticks, nil
 }
 
-// 数据返回为字符串：var hq_str_RU0="橡胶连续,225956,13145.00,13185.00,13005.00,13120.00,13115.00,13120.00,13120.00,13107.00,13265.00,1,6,434190,272890,沪,橡胶,2019-12-13,0,13455.000,13055.000,13455.000,12535.000,13455.000,11870.000,13455.000,11330.000,250.266";
+// 解析一行行情
 // 0：豆粕连续，名字
-// 1：145958，不明数字（难道是数据提供商代码？）
+// 1：145958，行情时间 hhmmss
 // 2：3170，开盘价
 // 3：3190，最高价
 // 4：3145，最低价
@@ -150,68 +166,51 @@ func (p *Provider) Stop() {
 // 15：连，大连商品交易所简称
 // 16：豆粕，品种名简称
 // 17：2013-06-28，日期
-
-// request data here 获取最新价
-func (p *Provider) getTicker(coinType string) (*Ticker, error) {
-	_, ok := coinMap[coinType]
-	// 不支持的交易对直接返回nil
-	if !ok {
-		println("coinType not sup", coinType)
-		return nil, nil
+func ParseQuote(line string) (*Quote, error) {
+	start := strings.Index(line, "hq_str_")
+	eq := strings.Index(line, "=\"")
+	if start < 0 || eq < start {
+		return nil, errors.New("body data err")
 	}
-
-	request := gorequest.New()
-	request = request.AppendHeader("User-Agent", "Chrome/39.0.2171.71")
-	request = request.AppendHeader("Accept-Encoding", "gzip, deflate")
-
-	_, body, errs := request.Get("http://hq.sinajs.cn/list=nf_RU0").Timeout(3 * time.Second).End()
-	if len(errs) > 0 {
-		return nil, errs[0]
+	symbol := line[start+len("hq_str_") : eq]
+	content := strings.TrimSuffix(strings.TrimSuffix(line[eq+2:], ";"), "\"")
+	if content == "" {
+		return nil, errNoData
 	}
 
-	data := strings.Split(body, ",")
+	data := strings.Split(content, ",")
 	if len(data) < 18 {
-		return nil, errors.New("body data err")
+		return nil, errors.New("contract " + symbol + " data err")
 	}
 
-	tick := &Ticker{
-		Sell: data[7],
-		Buy:  data[6],
-		Vol:  data[14],
-	}
+	return &Quote{
+		Symbol:       symbol,
+		Name:         data[0],
+		Time:         data[1],
+		Open:         data[2],
+		High:         data[3],
+		Low:          data[4],
+		PreClose:     data[5],
+		Bid:          data[6],
+		Ask:          data[7],
+		Last:         data[8],
+		Settle:       data[9],
+		PreSettle:    data[10],
+		BidVol:       data[11],
+		AskVol:       data[12],
+		OpenInterest: data[13],
+		Volume:       data[14],
+		Exchange:     data[15],
+		Variety:      data[16],
+		Date:         data[17],
+	}, nil
+}
 
-	// 随机波动
-	//current, _ := strconv.ParseFloat(data[8], 64)
-	//op := rand.Intn(21) - 10
-	//current = current + current*rand.Float64()*float64(op)/10000
-	//last := strconv.FormatFloat(current, 'f', 4, 64)
-	//
-	//if ret, _ := common.BcCmp(last, "12000.0000"); ret <= 0 {
-	//	fmt.Printf("now:%v currrent:%f, op:%d, last:%s \n", time.Now(), current, op, last)
-	//	for i, val := range data {
-	//		if i == 0 {
-	//			continue
-	//		}
-	//		fmt.Printf("%d:%+v  ", i, val)
-	//	}
-	//	print("\n")
-	//}
-
-	last := data[8]
-
-	if coinType == constant.CoinTypeRUCNY {
-		tick.High = last
-		tick.Open = last
-		tick.Last = last
-		tick.Low = last
-
-		return tick, nil
+// 行情时间 毫秒, 时间格式有误时为0
+func (q *Quote) Timestamp() int64 {
+	t, err := time.ParseInLocation("2006-01-02 150405", q.Date+" "+q.Time, location)
+	if err != nil {
+		return 0
 	}
-
-	tick.High = last
-	tick.Open = last
-	tick.Last = last
-	tick.Low = last
-
-	return tick, nil
+	return t.UnixNano() / 1e6
 }
@@ -0,0 +1,87 @@
+package sina
+
+import (
+	"testing"
+)
+
+const testBody = `var hq_str_nf_RU0="橡胶连续,225956,13145.00,13185.00,13005.00,13120.00,13115.00,13120.00,13120.00,13107.00,13265.00,1,6,434190,272890,沪,橡胶,2019-12-13,0,13455.000,13055.000,13455.000,12535.000,13455.000,11870.000,13455.000,11330.000,250.266";
+var hq_str_nf_CU0="沪铜连续,150000,48800.00,49010.00,48700.00,48820.00,48900.00,48910.00,48900.00,48860.00,48750.00,12,8,182230,95120,沪,沪铜,2019-12-13,0";
+var hq_str_nf_XX0="";
+`
+
+func TestDecodeTickers(t *testing.T) {
+	ticks, err := decodeTickers([]byte(testBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ticks) != 2 {
+		t.Fatalf("expect 2 ticks, got %d", len(ticks))
+	}
+	if ticks[0].Symbol != "nf_RU0" || ticks[0].Last != "13120.00" || ticks[0].Vol != "272890" || ticks[0].High != "13185.00" {
+		t.Fatalf("unexpected tick: %+v", ticks[0])
+	}
+	if ticks[0].Bid != "13115.00" || ticks[0].Ask != "13120.00" || ticks[0].BidSize != "1" || ticks[0].AskSize != "6" {
+		t.Fatalf("unexpected bid/ask: %+v", ticks[0])
+	}
+	if ticks[1].Symbol != "nf_CU0" || ticks[1].Last != "48900.00" || ticks[1].Low != "48700.00" {
+		t.Fatalf("unexpected tick: %+v", ticks[1])
+	}
+
+	if _, err := decodeTicker([]byte(`var hq_str_nf_XX0="";`)); err == nil {
+		t.Fatal("empty contract should return error")
+	}
+	if _, err := decodeTickers([]byte(`var hq_str_nf_RU0="橡胶连续,225956,13145.00";`)); err == nil {
+		t.Fatal("short data should return error")
+	}
+}
+
+func TestParseQuote(t *testing.T) {
+	quote, err := ParseQuote(`var hq_str_nf_M0="豆粕连续,145958,3170,3190,3145,3178,3153,3154,3154,3162,3169,1325,223,1371608,1611074,连,豆粕,2013-06-28";`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := Quote{
+		Symbol: "nf_M0", Name: "豆粕连续", Time: "145958", Open: "3170", High: "3190", Low: "3145", PreClose: "3178",
+		Bid: "3153", Ask: "3154", Last: "3154", Settle: "3162", PreSettle: "3169", BidVol: "1325", AskVol: "223",
+		OpenInterest: "1371608", Volume: "1611074", Exchange: "连", Variety: "豆粕", Date: "2013-06-28",
+	}
+	if *quote != expect {
+		t.Fatalf("unexpected quote: %+v", quote)
+	}
+	if ts := quote.Timestamp(); ts != 1372402798000 {
+		t.Fatalf("unexpected timestamp: %d", ts)
+	}
+}
+
+func TestCheckRollover(t *testing.T) {
+	symbol := "nf_rollover_test"
+	days := []struct {
+		date      string
+		settle    string
+		preSettle string
+		rollover  bool
+	}{
+		{"2019-12-12", "13000", "12950", false},
+		{"2019-12-12", "13100", "12950", false}, // 同一交易日更新结算价
+		{"2019-12-13", "13150", "13100", false}, // 昨结算与上一交易日结算价一致
+		{"2019-12-16", "13500", "13420", true},  // 主力合约切换
+		{"2019-12-17", "13480", "13500", false},
+	}
+
+	count := len(Rollovers())
+	for _, day := range days {
+		rollover := checkRollover(&Quote{Symbol: symbol, Date: day.date, Settle: day.settle, PreSettle: day.preSettle})
+		if (rollover != nil) != day.rollover {
+			t.Fatalf("%s: expect rollover %v, got %+v", day.date, day.rollover, rollover)
+		}
+	}
+
+	rollovers := Rollovers()
+	if len(rollovers) != count+1 {
+		t.Fatalf("expect %d rollovers, got %d", count+1, len(rollovers))
+	}
+	last := rollovers[len(rollovers)-1]
+	if last.Symbol != symbol || last.Date != "2019-12-16" || last.PrevDate != "2019-12-13" || last.PrevSettle != "13150" {
+		t.Fatalf("unexpected rollover: %+v", last)
+	}
+}
@@ -0,0 +1,375 @@
+package provider
+
+import (
+	"bitcoin-kline/config"
+	"bitcoin-kline/constant"
+	"bitcoin-kline/logger"
+	"bitcoin-kline/model"
+	"crypto/tls"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"golang.org/x/net/proxy"
+	"golang.org/x/net/websocket"
+)
+
+// websocket推送模式的数据商
+// 连接断开后按指数退避重连, 重连成功后重新订阅
+// 收到的最新行情按秒推送至readChan, 与轮询模式保持一致
+// 配置了Trade时为逐笔成交模式, 见trade.go
+// 连接地址与代理可在配置[provider.<name>]的ws_url、proxy项覆盖
+
+const (
+	streamMinBackoff   = time.Second
+	streamMaxBackoff   = time.Minute
+	streamDialTimeout  = 10 * time.Second
+	streamReadTimeout  = 30 * time.Second // 超过该时间未收到任何消息视为连接失效
+	streamPingInterval = 20 * time.Second
+	streamOrigin       = "http://localhost/"
+)
+
+// 交易所websocket协议适配
+type StreamHandler interface {
+	// 连接地址, base为配置的websocket地址
+	Url(base string, symbols []string) string
+	// 订阅消息, 每次连接成功后发送
+	Subscribe(symbols []string) []string
+	// 客户端心跳消息, 为空则不发送
+	Ping() string
+	// 解析推送消息, reply不为空时回写给服务端(如心跳应答)
+	Decode(msg []byte) (ticks []*Tick, reply string, err error)
+}
+
+type StreamConfig struct {
+	Name       string            // 数据商名称
+	Origin     int               // 数据来源
+	CoinMap    map[string]string // 币种 -> 交易所交易对
+	Url        string            // websocket地址, 配置项ws_url
+	Proxy      string            // 代理地址, 配置项proxy
+	Handler    StreamHandler
+	Trade      TradeHandler // 逐笔成交模式使用, 见trade.go
+	PerSymbol  bool         // 每个交易对单独建立连接
+	VolumeUnit string       // 成交量计量单位, VolumeBase(默认)或VolumeQuote
+}
+
+type StreamProvider struct {
+	conf     StreamConfig
+	coinMap  map[string]string // 交易所交易对 -> 币种
+	readChan map[string]chan *model.Kline
+	latest   map[string]*Tick     // 币种 -> 最新行情
+	bars     map[string]*tradeBar // 币种 -> 本秒成交汇总, 仅逐笔成交模式
+
+	breakMainLogic chan bool // 结束命令管道
+	sync.RWMutex
+	sync.WaitGroup
+}
+
+func NewStreamProvider(conf StreamConfig) *StreamProvider {
+	section := "provider." + conf.Name
+	if val := config.GetConfig(section, "ws_url"); val != "" {
+		conf.Url = val
+	}
+	if val := config.GetConfig(section, "proxy"); val != "" {
+		conf.Proxy = val
+	}
+
+	p := &StreamProvider{
+		conf:           conf,
+		coinMap:        make(map[string]string),
+		readChan:       make(map[string]chan *model.Kline),
+		latest:         make(map[string]*Tick),
+		bars:           make(map[string]*tradeBar),
+		breakMainLogic: make(chan bool),
+	}
+
+	for _, coinType := range config.SupportCoinTypes {
+		if symbol, ok := conf.CoinMap[coinType]; ok {
+			p.coinMap[symbol] = coinType
+			p.readChan[coinType] = make(chan *model.Kline)
+		}
+	}
+
+	return p
+}
+
+func (p *StreamProvider) ReadChan(coinType string) <-chan *model.Kline {
+	return p.readChan[coinType]
+}
+
+func (p *StreamProvider) StartCollect() {
+	symbols := make([]string, 0)
+	for symbol, coinType := range p.coinMap {
+		symbols = append(symbols, symbol)
+
+		p.Add(1)
+		go func(c string) {
+			defer p.Done()
+			p.emitLoop(c)
+		}(coinType)
+	}
+	if len(symbols) == 0 {
+		return
+	}
+
+	groups := [][]string{symbols}
+	if p.conf.PerSymbol {
+		groups = groups[:0]
+		for _, symbol := range symbols {
+			groups = append(groups, []string{symbol})
+		}
+	}
+	for _, group := range groups {
+		p.Add(1)
+		go func(s []string) {
+			defer p.Done()
+			p.connLoop(s)
+		}(group)
+	}
+}
+
+func (p *StreamProvider) Stop() {
+	close(p.breakMainLogic)
+	p.Wait()
+}
+
+// 维持连接, 断开后退避重连
+func (p *StreamProvider) connLoop(symbols []string) {
+	backoff := streamMinBackoff
+	for {
+		received, err := p.serve(symbols)
+		p.resetLatest(symbols)
+		p.resetBars(symbols)
+
+		select {
+		case <-p.breakMainLogic:
+			return
+		default:
+		}
+
+		if received {
+			backoff = streamMinBackoff
+		}
+		if err != nil {
+			p.reportFailure(symbols, err)
+			logger.Error("StreamProvider_serve", map[string]interface{}{"provider": p.conf.Name, "symbols": symbols, "backoff": backoff.String()}, err.Error())
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-p.breakMainLogic:
+			return
+		}
+
+		backoff *= 2
+		if backoff > streamMaxBackoff {
+			backoff = streamMaxBackoff
+		}
+	}
+}
+
+// 建立连接并订阅, 阻塞读取推送直到连接出错或结束
+// received 表示本次连接是否收到过行情
+func (p *StreamProvider) serve(symbols []string) (received bool, err error) {
+	conn, err := p.dial(p.protocol().Url(p.conf.Url, symbols))
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	done := make(chan bool)
+	defer close(done)
+	go func() {
+		ping := p.protocol().Ping()
+		t := time.NewTicker(streamPingInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				if ping != "" {
+					_ = websocket.Message.Send(conn, ping)
+				}
+			case <-p.breakMainLogic:
+				_ = conn.Close()
+				return
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for _, msg := range p.protocol().Subscribe(symbols) {
+		if err := websocket.Message.Send(conn, msg); err != nil {
+			return false, err
+		}
+	}
+
+	for {
+		if err := conn.SetReadDeadline(time.Now().Add(streamReadTimeout)); err != nil {
+			return received, err
+		}
+		var msg []byte
+		if err := websocket.Message.Receive(conn, &msg); err != nil {
+			return received, err
+		}
+
+		ok, reply, err := p.decode(msg)
+		if err != nil {
+			p.reportFailure(symbols, err)
+			logger.Error("StreamProvider_decode", p.conf.Name, err.Error())
+			continue
+		}
+		if reply != "" {
+			if err := websocket.Message.Send(conn, reply); err != nil {
+				return received, err
+			}
+		}
+		if ok {
+			received = true
+		}
+	}
+}
+
+// websocket连接协议, StreamHandler与TradeHandler共用
+type streamProtocol interface {
+	Url(base string, symbols []string) string
+	Subscribe(symbols []string) []string
+	Ping() string
+}
+
+func (p *StreamProvider) protocol() streamProtocol {
+	if p.conf.Trade != nil {
+		return p.conf.Trade
+	}
+	return p.conf.Handler
+}
+
+// 解析推送消息并缓存行情或成交, ok 表示收到了已订阅交易对的数据
+func (p *StreamProvider) decode(msg []byte) (ok bool, reply string, err error) {
+	if p.conf.Trade != nil {
+		trades, reply, err := p.conf.Trade.Decode(msg)
+		return p.addTrades(trades), reply, err
+	}
+
+	ticks, reply, err := p.conf.Handler.Decode(msg)
+	for _, tick := range ticks {
+		if p.setLatest(tick) {
+			ok = true
+		}
+	}
+	return ok, reply, err
+}
+
+// 建立websocket连接, 配置了代理时经代理转发
+func (p *StreamProvider) dial(rawUrl string) (*websocket.Conn, error) {
+	conf, err := websocket.NewConfig(rawUrl, streamOrigin)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{Timeout: streamDialTimeout}
+	if p.conf.Proxy == "" {
+		conf.Dialer = dialer
+		return websocket.DialConfig(conf)
+	}
+
+	proxyUrl, err := url.Parse(p.conf.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	forward, err := proxy.FromURL(proxyUrl, dialer)
+	if err != nil {
+		return nil, err
+	}
+
+	host := conf.Location.Host
+	if conf.Location.Port() == "" {
+		port := "80"
+		if conf.Location.Scheme == "wss" {
+			port = "443"
+		}
+		host = net.JoinHostPort(host, port)
+	}
+	rawConn, err := forward.Dial("tcp", host)
+	if err != nil {
+		return nil, err
+	}
+	var rwc net.Conn = rawConn
+	if conf.Location.Scheme == "wss" {
+		rwc = tls.Client(rawConn, &tls.Config{ServerName: conf.Location.Hostname()})
+	}
+
+	conn, err := websocket.NewClient(conf, rwc)
+	if err != nil {
+		_ = rawConn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (p *StreamProvider) setLatest(tick *Tick) bool {
+	coinType, ok := p.coinMap[tick.Symbol]
+	if !ok {
+		return false
+	}
+	ReportSuccess(p.conf.Name, coinType, 0)
+
+	p.Lock()
+	defer p.Unlock()
+	p.latest[coinType] = tick
+	return true
+}
+
+func (p *StreamProvider) reportFailure(symbols []string, err error) {
+	for _, symbol := range symbols {
+		ReportFailure(p.conf.Name, p.coinMap[symbol], 0, err)
+	}
+}
+
+func (p *StreamProvider) getLatest(coinType string) *Tick {
+	p.RLock()
+	defer p.RUnlock()
+	return p.latest[coinType]
+}
+
+// 连接断开后清除缓存, 避免推送过期行情
+func (p *StreamProvider) resetLatest(symbols []string) {
+	p.Lock()
+	defer p.Unlock()
+	for _, symbol := range symbols {
+		delete(p.latest, p.coinMap[symbol])
+	}
+}
+
+// 每秒推送最新行情, 逐笔成交模式推送本秒成交汇总的k线
+func (p *StreamProvider) emitLoop(coinType string) {
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			if p.conf.Trade != nil {
+				if kline := p.takeBar(coinType); kline != nil {
+					p.handleKline(coinType, kline)
+				}
+				break
+			}
+			tick := p.getLatest(coinType)
+			if tick == nil {
+				break
+			}
+			p.handleKline(coinType, newKline(coinType, p.conf.Origin, p.conf.VolumeUnit, tick))
+		case <-p.breakMainLogic:
+			return
+		}
+	}
+}
+
+func (p *StreamProvider) handleKline(coinType string, kline *model.Kline) {
+	select {
+	case p.readChan[coinType] <- kline:
+	case <-time.After(time.Second * constant.ProviderDataExpireTime):
+	case <-p.breakMainLogic:
+	}
+}
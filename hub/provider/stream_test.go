@@ -0,0 +1,103 @@
+package provider_test
+
+import (
+	"bitcoin-kline/constant"
+	"bitcoin-kline/hub/provider"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// 本地websocket替身使用的消息格式
+type fakeStreamHandler struct{}
+
+func (h *fakeStreamHandler) Url(base string, symbols []string) string {
+	return base
+}
+
+func (h *fakeStreamHandler) Subscribe(symbols []string) []string {
+	return []string{"sub:" + strings.Join(symbols, ",")}
+}
+
+func (h *fakeStreamHandler) Ping() string {
+	return ""
+}
+
+func (h *fakeStreamHandler) Decode(msg []byte) ([]*provider.Tick, string, error) {
+	if string(msg) == "ping" {
+		return nil, "pong", nil
+	}
+	tick := &provider.Tick{}
+	if err := json.Unmarshal(msg, tick); err != nil {
+		return nil, "", err
+	}
+	return []*provider.Tick{tick}, "", nil
+}
+
+// 每个连接收到订阅后推送一条行情, 第一个连接推送后主动断开
+func newFakeStreamServer(t *testing.T, subscribed *int32) *httptest.Server {
+	return httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+		var sub string
+		if err := websocket.Message.Receive(conn, &sub); err != nil {
+			return
+		}
+		if sub != "sub:ethusdt" {
+			t.Errorf("unexpected subscribe message: %s", sub)
+			return
+		}
+		n := atomic.AddInt32(subscribed, 1)
+
+		price := "100.0000"
+		if n > 1 {
+			price = "200.0000"
+		}
+		msg, _ := json.Marshal(&provider.Tick{Symbol: "ethusdt", Last: price, Vol: "1"})
+		_ = websocket.Message.Send(conn, string(msg))
+		if n == 1 {
+			return
+		}
+
+		var reply string
+		_ = websocket.Message.Receive(conn, &reply)
+	}))
+}
+
+func TestStreamProvider(t *testing.T) {
+	var subscribed int32
+	server := newFakeStreamServer(t, &subscribed)
+	defer server.Close()
+
+	p := provider.NewStreamProvider(provider.StreamConfig{
+		Name:    constant.ProviderMock,
+		Origin:  constant.ProviderMockOriginType,
+		CoinMap: map[string]string{constant.CoinTypeETHUSDT: "ethusdt"},
+		Url:     "ws" + strings.TrimPrefix(server.URL, "http"),
+		Handler: &fakeStreamHandler{},
+	})
+	p.StartCollect()
+	defer p.Stop()
+
+	// 断线重连后重新订阅, 并收到新连接推送的行情
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case item := <-p.ReadChan(constant.CoinTypeETHUSDT):
+			if item.Origin != constant.ProviderMockOriginType || item.CoinType != constant.CoinTypeETHUSDT {
+				t.Fatalf("unexpected kline: %+v", item)
+			}
+			if item.Close == "200.0000" {
+				if atomic.LoadInt32(&subscribed) < 2 {
+					t.Fatal("stream provider did not resubscribe")
+				}
+				return
+			}
+		case <-timeout:
+			t.Fatalf("no data after reconnect, subscribed %d times", atomic.LoadInt32(&subscribed))
+		}
+	}
+}
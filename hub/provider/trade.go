@@ -0,0 +1,134 @@
+package provider
+
+import (
+	"bitcoin-kline/common"
+	"bitcoin-kline/model"
+	"time"
+)
+
+// 逐笔成交模式, 配置mode = trade启用
+// 复用websocket推送模式的连接管理, 订阅交易所逐笔成交, 每秒将本秒成交汇总为一根真实的OHLCV k线
+// 快照模式的k线开高低收相同, 逐笔成交模式可保留秒内的最高最低价
+// 本秒没有成交时以上一笔成交价推送成交量为0的k线, 连接后尚无成交时不推送
+// k线的交易所时间为最近一笔成交时间, 推送中断或长时间无成交时按过期报价丢弃, 成交稀少的币种可调大[provider]的freshness
+
+const (
+	SideBuy  = "buy"  // 主动买入
+	SideSell = "sell" // 主动卖出
+)
+
+// 交易所推送的逐笔成交
+type Trade struct {
+	Symbol string // 交易所交易对
+	Price  string // 成交价
+	Size   string // 成交数量, 以基础币种计量
+	Side   string // 主动成交方向, SideBuy或SideSell, 交易所未提供时为空
+	Time   int64  // 成交时间 毫秒
+}
+
+// 交易所逐笔成交websocket协议适配, 连接相关方法同StreamHandler
+type TradeHandler interface {
+	Url(base string, symbols []string) string
+	// 订阅逐笔成交, 每次连接成功后发送
+	Subscribe(symbols []string) []string
+	Ping() string
+	// 解析推送消息, reply不为空时回写给服务端(如心跳应答)
+	Decode(msg []byte) (trades []*Trade, reply string, err error)
+}
+
+// 一秒内的成交汇总
+type tradeBar struct {
+	open, high, low, close string
+	volume                 string // 基础币种成交量
+	quoteVolume            string // 报价币种成交额
+	trades                 int    // 成交笔数
+	time                   int64  // 最近一笔成交时间 毫秒
+}
+
+// 将一条推送中的成交计入所属币种本秒的k线, 同一推送不会拆分到两根k线
+func (p *StreamProvider) addTrades(trades []*Trade) bool {
+	p.Lock()
+	defer p.Unlock()
+
+	received := false
+	for _, trade := range trades {
+		if p.addTrade(trade) {
+			received = true
+		}
+	}
+	return received
+}
+
+// 调用方持有锁
+func (p *StreamProvider) addTrade(trade *Trade) bool {
+	coinType, ok := p.coinMap[trade.Symbol]
+	if !ok {
+		return false
+	}
+	amount, err := common.BcMul(trade.Price, trade.Size, 18)
+	if err != nil {
+		return false
+	}
+	ReportSuccess(p.conf.Name, coinType, 0)
+
+	bar, ok := p.bars[coinType]
+	if !ok || bar.trades == 0 {
+		bar = &tradeBar{open: trade.Price, high: trade.Price, low: trade.Price, volume: "0", quoteVolume: "0"}
+		p.bars[coinType] = bar
+	}
+	if ret, _ := common.BcCmp(trade.Price, bar.high); ret > 0 {
+		bar.high = trade.Price
+	}
+	if ret, _ := common.BcCmp(trade.Price, bar.low); ret < 0 {
+		bar.low = trade.Price
+	}
+	bar.close = trade.Price
+	bar.volume, _ = common.BcAdd(bar.volume, trade.Size, 4)
+	bar.quoteVolume, _ = common.BcAdd(bar.quoteVolume, amount, 4)
+	bar.trades++
+	if trade.Time > bar.time {
+		bar.time = trade.Time
+	}
+	return true
+}
+
+// 取出本秒k线, 并以收盘价开始下一秒
+func (p *StreamProvider) takeBar(coinType string) *model.Kline {
+	p.Lock()
+	defer p.Unlock()
+	bar, ok := p.bars[coinType]
+	if !ok {
+		return nil
+	}
+	p.bars[coinType] = &tradeBar{open: bar.close, high: bar.close, low: bar.close, close: bar.close, volume: "0", quoteVolume: "0", time: bar.time}
+
+	received := time.Now()
+	now := received.Unix()
+	kline := &model.Kline{
+		CoinType:    coinType,
+		High:        bar.high,
+		Low:         bar.low,
+		Open:        bar.open,
+		Close:       bar.close,
+		CreateTime:  now,
+		UpdateTime:  now,
+		TimeScale:   "1s",
+		Origin:      p.conf.Origin,
+		Volume:      bar.volume,
+		QuoteVolume: bar.quoteVolume,
+		Trades:      bar.trades,
+
+		ExchangeTime: bar.time,
+		ReceiveTime:  received.UnixNano() / 1e6,
+	}
+	return kline
+}
+
+// 连接断开后清除未完成的k线
+func (p *StreamProvider) resetBars(symbols []string) {
+	p.Lock()
+	defer p.Unlock()
+	for _, symbol := range symbols {
+		delete(p.bars, p.coinMap[symbol])
+	}
+}
@@ -0,0 +1,93 @@
+package provider_test
+
+import (
+	"bitcoin-kline/constant"
+	"bitcoin-kline/hub/provider"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// 本地websocket替身使用的逐笔成交消息格式
+type fakeTradeHandler struct{}
+
+func (h *fakeTradeHandler) Url(base string, symbols []string) string {
+	return base
+}
+
+func (h *fakeTradeHandler) Subscribe(symbols []string) []string {
+	return []string{"sub:" + strings.Join(symbols, ",")}
+}
+
+func (h *fakeTradeHandler) Ping() string {
+	return ""
+}
+
+func (h *fakeTradeHandler) Decode(msg []byte) ([]*provider.Trade, string, error) {
+	trades := make([]*provider.Trade, 0)
+	if err := json.Unmarshal(msg, &trades); err != nil {
+		return nil, "", err
+	}
+	return trades, "", nil
+}
+
+func TestTradeProvider(t *testing.T) {
+	now := time.Now().UnixNano() / 1e6
+	server := httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+		var sub string
+		if err := websocket.Message.Receive(conn, &sub); err != nil {
+			return
+		}
+		msg, _ := json.Marshal([]*provider.Trade{
+			{Symbol: "ethusdt", Price: "100", Size: "1", Side: provider.SideBuy, Time: now},
+			{Symbol: "ethusdt", Price: "103", Size: "0.5", Side: provider.SideBuy, Time: now + 1},
+			{Symbol: "ethusdt", Price: "98", Size: "2", Side: provider.SideSell, Time: now + 2},
+			{Symbol: "ethusdt", Price: "101", Size: "1", Side: provider.SideSell, Time: now + 3},
+		})
+		_ = websocket.Message.Send(conn, string(msg))
+
+		var reply string
+		_ = websocket.Message.Receive(conn, &reply)
+	}))
+	defer server.Close()
+
+	p := provider.NewStreamProvider(provider.StreamConfig{
+		Name:    constant.ProviderMock,
+		Origin:  constant.ProviderMockOriginType,
+		CoinMap: map[string]string{constant.CoinTypeETHUSDT: "ethusdt"},
+		Url:     "ws" + strings.TrimPrefix(server.URL, "http"),
+		Trade:   &fakeTradeHandler{},
+	})
+	p.StartCollect()
+	defer p.Stop()
+
+	// 同一秒内的成交汇总为一根k线, 之后无成交时推送以收盘价持平的k线
+	select {
+	case item := <-p.ReadChan(constant.CoinTypeETHUSDT):
+		if item.Open != "100" || item.High != "103" || item.Low != "98" || item.Close != "101" {
+			t.Fatalf("unexpected ohlc: %+v", item)
+		}
+		if item.Volume != "4.5000" || item.QuoteVolume != "448.5000" || item.Trades != 4 || item.ExchangeTime != now+3 {
+			t.Fatalf("unexpected volume: %+v", item)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no trade kline")
+	}
+
+	select {
+	case item := <-p.ReadChan(constant.CoinTypeETHUSDT):
+		if item.Open != "101" || item.High != "101" || item.Low != "101" || item.Close != "101" || item.Trades != 0 || item.Volume != "0" {
+			t.Fatalf("unexpected idle kline: %+v", item)
+		}
+		// 无成交时沿用最近一笔成交时间, 推送中断后可按过期丢弃
+		if item.ExchangeTime != now+3 {
+			t.Fatalf("idle kline should carry last trade time: %+v", item)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no idle kline")
+	}
+}
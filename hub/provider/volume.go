@@ -0,0 +1,33 @@
+package provider
+
+import "bitcoin-kline/common"
+
+// 交易所成交量的计量单位, 在PollConfig、StreamConfig、HistoryConfig的VolumeUnit中声明
+const (
+	VolumeBase  = "base"  // 以基础币种计量, 如ETH/USDT的ETH, 期货为手数(默认)
+	VolumeQuote = "quote" // 以报价币种计量, 如ETH/USDT的USDT
+)
+
+// 按计量单位换算为基础币种与报价币种成交量, 成交量或价格无效时返回空
+func NormalizeVolume(vol, price, unit string) (string, string) {
+	if vol == "" || price == "" {
+		return "", ""
+	}
+	if ret, err := common.BcCmp(price, "0"); err != nil || ret <= 0 {
+		return "", ""
+	}
+	if unit == VolumeQuote {
+		base, err := common.BcDiv(vol, price, 4)
+		if err != nil {
+			return "", ""
+		}
+		quote, _ := common.BcAdd(vol, "0", 4)
+		return base, quote
+	}
+	quote, err := common.BcMul(vol, price, 4)
+	if err != nil {
+		return "", ""
+	}
+	base, _ := common.BcAdd(vol, "0", 4)
+	return base, quote
+}
@@ -0,0 +1,25 @@
+package provider_test
+
+import (
+	"bitcoin-kline/hub/provider"
+	"testing"
+)
+
+func TestNormalizeVolume(t *testing.T) {
+	cases := []struct {
+		vol, price, unit string
+		base, quote      string
+	}{
+		{"10", "200", provider.VolumeBase, "10.0000", "2000.0000"},
+		{"2000", "200", provider.VolumeQuote, "10.0000", "2000.0000"},
+		{"10", "200", "", "10.0000", "2000.0000"},
+		{"", "200", provider.VolumeBase, "", ""},
+		{"10", "0", provider.VolumeQuote, "", ""},
+	}
+	for _, item := range cases {
+		base, quote := provider.NormalizeVolume(item.vol, item.price, item.unit)
+		if base != item.base || quote != item.quote {
+			t.Errorf("%+v: got base %s, quote %s", item, base, quote)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package zb
+
+import (
+	"bitcoin-kline/constant"
+	"bitcoin-kline/hub/provider"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+func init() {
+	provider.RegisterHistory(provider.HistoryConfig{
+		Name:       constant.ProviderZB,
+		Origin:     constant.ProviderZBOriginType,
+		VolumeUnit: provider.VolumeBase,
+		CoinMap:    zbCoinMap,
+		BaseUrl:    baseUrl,
+		Limit:      1000,
+		Url:        klineUrl,
+		Decode:     decodeKline,
+	})
+}
+
+// 1分钟k线, since为毫秒, 单次最多1000根
+func klineUrl(base, symbol string, start, end int64) string {
+	return fmt.Sprintf("%s/data/v1/kline?market=%s&type=1min&since=%d&size=1000", base, symbol, start*1000)
+}
+
+// data: [[时间, 开盘价, 最高价, 最低价, 收盘价, 成交量]]
+func decodeKline(body []byte) ([]*provider.Candle, error) {
+	resp := &struct {
+		Data  [][]float64 `json:"data"`
+		Error string      `json:"error"`
+	}{}
+	if err := json.Unmarshal(body, resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+
+	candles := make([]*provider.Candle, 0)
+	for _, item := range resp.Data {
+		if len(item) < 6 {
+			return nil, errors.New("kline data err")
+		}
+		candles = append(candles, &provider.Candle{
+			Time:  int64(item[0]) / 1000,
+			Open:  strconv.FormatFloat(item[1], 'f', 4, 64),
+			High:  strconv.FormatFloat(item[2], 'f', 4, 64),
+			Low:   strconv.FormatFloat(item[3], 'f', 4, 64),
+			Close: strconv.FormatFloat(item[4], 'f', 4, 64),
+			Vol:   strconv.FormatFloat(item[5], 'f', 4, 64),
+		})
+	}
+	return candles, nil
+}
@@ -0,0 +1,92 @@
+package zb
+
+import (
+	"bitcoin-kline/constant"
+	"bitcoin-kline/hub/provider"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// websocket文档：https://www.zb.com/api#websocket
+// 频道格式为 ethusdt_ticker, 交易对去掉下划线
+
+const wsUrl = "wss://api.zb.cn/websocket"
+
+type streamHandler struct{}
+
+type streamResponse struct {
+	DataType string  `json:"dataType"`
+	Channel  string  `json:"channel"`
+	Code     int     `json:"code"`
+	Message  string  `json:"message"`
+	Date     string  `json:"date"` // 行情时间 毫秒
+	Ticker   *Ticker `json:"ticker"`
+}
+
+func NewStreamProvider() *provider.StreamProvider {
+	return provider.NewStreamProvider(provider.StreamConfig{
+		Name:       constant.ProviderZB,
+		Origin:     constant.ProviderZBOriginType,
+		VolumeUnit: provider.VolumeBase,
+		CoinMap:    zbCoinMap,
+		Url:        wsUrl,
+		Handler:    &streamHandler{},
+	})
+}
+
+func (h *streamHandler) Url(base string, symbols []string) string {
+	return base
+}
+
+func (h *streamHandler) Subscribe(symbols []string) []string {
+	msgs := make([]string, 0)
+	for _, symbol := range symbols {
+		msgs = append(msgs, fmt.Sprintf(`{"event":"addChannel","channel":"%s_ticker"}`, strings.Replace(symbol, "_", "", -1)))
+	}
+	return msgs
+}
+
+func (h *streamHandler) Ping() string {
+	return ""
+}
+
+func (h *streamHandler) Decode(msg []byte) ([]*provider.Tick, string, error) {
+	resp := &streamResponse{}
+	if err := json.Unmarshal(msg, resp); err != nil {
+		return nil, "", err
+	}
+	if resp.Code != 0 && resp.Code != 1000 {
+		return nil, "", errors.New(resp.Message)
+	}
+	if resp.DataType != "ticker" || resp.Ticker == nil {
+		return nil, "", nil
+	}
+
+	// 频道 ethusdt_ticker 还原为交易对 eth_usdt
+	channel := strings.TrimSuffix(resp.Channel, "_ticker")
+	symbol := ""
+	for _, s := range zbCoinMap {
+		if strings.Replace(s, "_", "", -1) == channel {
+			symbol = s
+			break
+		}
+	}
+	if symbol == "" {
+		return nil, "", errors.New("channel invalid: " + resp.Channel)
+	}
+
+	date, _ := strconv.ParseInt(resp.Date, 10, 64)
+	tick := &provider.Tick{
+		Symbol: symbol,
+		Last:   resp.Ticker.Last,
+		Vol:    resp.Ticker.Vol,
+		Time:   date,
+		Bid:    resp.Ticker.Buy,
+		Ask:    resp.Ticker.Sell,
+	}
+	return []*provider.Tick{tick}, "", nil
+}
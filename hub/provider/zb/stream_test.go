@@ -0,0 +1,45 @@
+package zb
+
+import (
+	"bitcoin-kline/hub/provider"
+	"testing"
+)
+
+func TestStreamSubscribe(t *testing.T) {
+	msgs := (&streamHandler{}).Subscribe([]string{"eth_usdt", "btc_usdt"})
+	expect := []string{`{"event":"addChannel","channel":"ethusdt_ticker"}`, `{"event":"addChannel","channel":"btcusdt_ticker"}`}
+	if len(msgs) != len(expect) || msgs[0] != expect[0] || msgs[1] != expect[1] {
+		t.Fatalf("unexpected subscribe: %v", msgs)
+	}
+}
+
+func TestStreamDecode(t *testing.T) {
+	cases := []struct {
+		name  string
+		msg   string
+		ticks []provider.Tick
+		err   bool
+	}{
+		{name: "ticker", msg: `{"dataType":"ticker","channel":"ethusdt_ticker","date":"1576209600123","ticker":{"high":"144.8","low":"140.9","last":"143.21","sell":"143.22","buy":"143.2","vol":"179000.1"}}`,
+			ticks: []provider.Tick{{Symbol: "eth_usdt", Last: "143.21", Vol: "179000.1", Time: 1576209600123, Bid: "143.2", Ask: "143.22"}}},
+		{name: "success", msg: `{"code":1000,"message":"操作成功","channel":"ethusdt_ticker"}`},
+		{name: "api error", msg: `{"code":1007,"message":"channel不存在","channel":"xxxusdt_ticker"}`, err: true},
+		{name: "unknown channel", msg: `{"dataType":"ticker","channel":"xxxusdt_ticker","date":"1576209600123","ticker":{"last":"1"}}`, err: true},
+		{name: "truncated", msg: `{"dataType":"ticker","channel":"ethusdt_ticker","ticker":{`, err: true},
+		{name: "wrong type", msg: `{"dataType":"ticker","date":1576209600123}`, err: true},
+		{name: "empty", msg: "", err: true},
+	}
+	h := &streamHandler{}
+	for _, c := range cases {
+		ticks, reply, err := h.Decode([]byte(c.msg))
+		if (err != nil) != c.err || reply != "" || len(ticks) != len(c.ticks) {
+			t.Errorf("%s: unexpected result %v %q %v", c.name, ticks, reply, err)
+			continue
+		}
+		for i, tick := range ticks {
+			if *tick != c.ticks[i] {
+				t.Errorf("%s: expect %+v, got %+v", c.name, c.ticks[i], *tick)
+			}
+		}
+	}
+}
@@ -1,16 +1,11 @@
 package zb
 
 import (
-	"bitcoin-kline/config"
 	"bitcoin-kline/constant"
-	"bitcoin-kline/logger"
-	"bitcoin-kline/model"
+	"bitcoin-kline/hub/provider"
 	"encoding/json"
-	"os"
-	"sync"
-	"time"
+	"strconv"
 
-	"github.com/parnurzeal/gorequest"
 	"github.com/pkg/errors"
 )
 
@@ -20,12 +15,7 @@ import (
 // https://www.zb.com/api#API%E4%BB%8B%E7%BB%8DAPI%E6%8E%A5%E5%8F%A3%E8%AF%B4%E6%98%8E
 // ticker接口：https://www.zb.com/api#pmtjkvevzyqinkb
 
-type Provider struct {
-	readChan map[string]chan *model.Kline
-
-	breakMainLogic chan bool // 结束命令管道
-	sync.WaitGroup
-}
+const baseUrl = "http://api.zb.cn"
 
 type Ticker struct {
 	High string `json:"high"` // 最高价
@@ -37,7 +27,7 @@ type Ticker struct {
 }
 
 type ApiResponse struct {
-	Date   string  `json:"date"`
+	Date   string  `json:"date"` // 行情时间 毫秒
 	Ticker *Ticker `json:"ticker"`
 	Error  string  `json:"error"`
 }
@@ -49,107 +39,33 @@ var (
 	}
 )
 
-func NewZbProvider() *Provider {
-	p := &Provider{
-		readChan:       make(map[string]chan *model.Kline),
-		breakMainLogic: make(chan bool),
-	}
-
-	for _, coinTyp := range config.SupportCoinTypes {
-		p.readChan[coinTyp] = make(chan *model.Kline)
-	}
-
-	return p
+func init() {
+	provider.Register(constant.ProviderZB, func() provider.Provider { return NewZbProvider() })
+	provider.RegisterStream(constant.ProviderZB, func() provider.Provider { return NewStreamProvider() })
 }
 
-func (p *Provider) ReadChan(coinType string) <-chan *model.Kline {
-	return p.readChan[coinType]
+func NewZbProvider() *provider.PollProvider {
+	return provider.NewPollProvider(provider.PollConfig{
+		Name:       constant.ProviderZB,
+		Origin:     constant.ProviderZBOriginType,
+		VolumeUnit: provider.VolumeBase,
+		CoinMap:    zbCoinMap,
+		UserAgent:  "Chrome/39.0.2171.71",
+		BaseUrl:    baseUrl,
+		RateLimit:  60,
+		Url:        tickerUrl,
+		Decode:     decodeTicker,
+	})
 }
 
-func (p *Provider) StartCollect() {
-	for _, coinType := range config.SupportCoinTypes {
-		// 不支持的交易对跳过
-		if _, ok := zbCoinMap[coinType]; ok {
-			p.Add(1)
-			go func(c string) {
-				defer p.Done()
-				p.loop(c)
-			}(coinType)
-		}
-	}
+// 获取最新价
+func tickerUrl(base, symbol string) string {
+	return base + "/data/v1/ticker?market=" + symbol
 }
 
-func (p *Provider) loop(params ...interface{}) {
-	if len(params) <= 0 {
-		return
-	}
-	coinType := params[0].(string)
-	t := time.NewTicker(time.Second)
-	defer t.Stop()
-
-	for {
-		select {
-		case <-t.C:
-			kline, err := p.getTicker(coinType)
-			if err != nil || kline == nil {
-				logger.Error("ZbProvider_getTicker", coinType, err.Error())
-				break
-			}
-			now := time.Now().Unix()
-
-			item := &model.Kline{
-				CoinType:    coinType,
-				High:        kline.Last,
-				Low:         kline.Last,
-				Open:        kline.Last,
-				Close:       kline.Last,
-				CreateTime:  now,
-				UpdateTime:  now,
-				TimeScale:   "1s",
-				Origin:      constant.ProviderZBOriginType,
-				OriginPrice: "",
-				Volume:      kline.Vol,
-			}
-
-			p.handleKline(coinType, item)
-			break
-		case <-p.breakMainLogic:
-			return
-		}
-	}
-}
-
-func (p *Provider) handleKline(coinType string, kline *model.Kline) {
-	select {
-	case p.readChan[coinType] <- kline:
-
-	case <-time.After(time.Second * constant.ProviderDataExpireTime):
-	case <-p.breakMainLogic:
-
-	}
-}
-
-func (p *Provider) Stop() {
-	close(p.breakMainLogic)
-	p.Wait()
-}
-
-// request data here 获取最新价
-func (p *Provider) getTicker(coinType string) (*Ticker, error) {
-	zbCoinType := zbCoinMap[coinType]
-	request := gorequest.New()
-	request = request.AppendHeader("User-Agent", "Chrome/39.0.2171.71")
-	if os.Getenv("RUNMODE") == "dev" || config.CURMODE == "dev" {
-		request = request.Proxy("socks5://127.0.0.1:1088")
-	}
-
-	_, body, errs := request.Get("http://api.zb.cn/data/v1/ticker?market=" + zbCoinType).Timeout(3 * time.Second).End()
-	if len(errs) > 0 {
-		return nil, errs[0]
-	}
-
+func decodeTicker(body []byte) (*provider.Tick, error) {
 	resp := &ApiResponse{}
-	if err := json.Unmarshal([]byte(body), resp); err != nil {
+	if err := json.Unmarshal(body, resp); err != nil {
 		return nil, err
 	}
 	if resp.Error != "" {
@@ -161,5 +77,12 @@ func (p *Provider) getTicker(coinType string) (*Ticker, error) {
 		return nil, errors.New("data invalid")
 	}
 
-	return tick, nil
+	date, _ := strconv.ParseInt(resp.Date, 10, 64)
+	return &provider.Tick{
+		Last: tick.Last,
+		Vol:  tick.Vol,
+		Time: date,
+		Bid:  tick.Buy,
+		Ask:  tick.Sell,
+	}, nil
 }
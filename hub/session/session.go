@@ -0,0 +1,224 @@
+package session
+
+import (
+	"bitcoin-kline/config"
+	"bitcoin-kline/logger"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 交易时段日历, 用于商品期货等非7x24小时交易的品种
+// 交易时段外不聚合数据、不生成k线, 每个交易时段的第一根与最后一根k线分别标记为开盘、收盘
+// 在[session.<币种>]中配置, 未配置的币种视为全天交易:
+// hours: 交易时段, 逗号分隔, 如 09:00-10:15,10:30-11:30,13:30-15:00,21:00-01:00
+//        开始时间晚于18:00或跨零点的为夜盘, 夜盘归属下一交易日, 节假日前一晚无夜盘
+// holidays: 休市日期, 逗号分隔, 连续日期可用~, 如 2020-01-24~2020-01-31, 与[session]的holidays合并
+// [session]中可配置全局休市日期holidays与交易所所在时区utc_offset(小时, 默认8)
+// 休市日期需每年更新, 启动时当年未配置休市日期的币种记录错误日志
+
+const (
+	Open  = "open"  // 交易时段第一根k线
+	Close = "close" // 交易时段最后一根k线
+
+	dateLayout     = "2006-01-02"
+	nightStart     = 18 * 3600
+	defaultOffset  = 8
+	sectionSession = "session"
+)
+
+type period struct {
+	start int // 当日秒数
+	end   int
+	night bool
+}
+
+type Calendar struct {
+	periods  []period
+	holidays map[string]bool
+	location *time.Location
+}
+
+var (
+	calendars    = make(map[string]*Calendar) // 币种 -> 交易日历
+	calendarLock sync.RWMutex
+)
+
+// 按配置加载各币种的交易日历
+func InitSession() error {
+	offset := defaultOffset
+	if config.GetConfig(sectionSession, "utc_offset") != "" {
+		offset = config.GetConfigInt(sectionSession, "utc_offset")
+	}
+	location := time.FixedZone(fmt.Sprintf("UTC%+d", offset), offset*3600)
+	holidays := config.GetConfig(sectionSession, "holidays")
+
+	items := make(map[string]*Calendar)
+	for _, coinType := range config.SupportCoinTypes {
+		section := sectionSession + "." + coinType
+		hours := config.GetConfig(section, "hours")
+		if hours == "" {
+			continue
+		}
+		c, err := NewCalendar(hours, holidays+","+config.GetConfig(section, "holidays"), location)
+		if err != nil {
+			return fmt.Errorf("%s session config err: %s", coinType, err.Error())
+		}
+		items[coinType] = c
+
+		if year := time.Now().In(location).Year(); !c.HasHolidays(year) {
+			logger.Error("session_InitSession", coinType, fmt.Sprintf("no holidays configured for %d, sessions will open on holidays", year))
+		}
+	}
+
+	calendarLock.Lock()
+	defer calendarLock.Unlock()
+	calendars = items
+	return nil
+}
+
+// 币种的交易日历, 全天交易的币种返回nil
+func Get(coinType string) *Calendar {
+	calendarLock.RLock()
+	defer calendarLock.RUnlock()
+	return calendars[coinType]
+}
+
+// 设置币种的交易日历, c为nil时视为全天交易
+func Set(coinType string, c *Calendar) {
+	calendarLock.Lock()
+	defer calendarLock.Unlock()
+	if c == nil {
+		delete(calendars, coinType)
+		return
+	}
+	calendars[coinType] = c
+}
+
+func NewCalendar(hours, holidays string, location *time.Location) (*Calendar, error) {
+	c := &Calendar{
+		periods:  make([]period, 0),
+		holidays: make(map[string]bool),
+		location: location,
+	}
+
+	for _, item := range strings.Split(hours, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.Split(item, "-")
+		if len(parts) != 2 {
+			return nil, errors.New("invalid hours " + item)
+		}
+		start, err := parseClock(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseClock(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		if start == end {
+			return nil, errors.New("invalid hours " + item)
+		}
+		c.periods = append(c.periods, period{start: start, end: end, night: start >= nightStart || end < start})
+	}
+	if len(c.periods) == 0 {
+		return nil, errors.New("hours required")
+	}
+
+	for _, item := range strings.Split(holidays, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.Split(item, "~")
+		from, err := time.Parse(dateLayout, strings.TrimSpace(parts[0]))
+		if err != nil {
+			return nil, err
+		}
+		to := from
+		if len(parts) > 1 {
+			if to, err = time.Parse(dateLayout, strings.TrimSpace(parts[1])); err != nil {
+				return nil, err
+			}
+		}
+		for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+			c.holidays[d.Format(dateLayout)] = true
+		}
+	}
+
+	return c, nil
+}
+
+// 是否配置了该年的休市日期
+func (c *Calendar) HasHolidays(year int) bool {
+	prefix := fmt.Sprintf("%04d-", year)
+	for date := range c.holidays {
+		if strings.HasPrefix(date, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// hh:mm转为当日秒数
+func parseClock(val string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(val))
+	if err != nil {
+		return 0, errors.New("invalid time " + val)
+	}
+	return t.Hour()*3600 + t.Minute()*60, nil
+}
+
+// 是否处于交易时段, nil表示全天交易
+func (c *Calendar) InSession(t time.Time) bool {
+	if c == nil {
+		return true
+	}
+
+	t = t.In(c.location)
+	sec := t.Hour()*3600 + t.Minute()*60 + t.Second()
+	for _, p := range c.periods {
+		switch {
+		case p.start < p.end:
+			if sec < p.start || sec >= p.end {
+				continue
+			}
+			if p.night {
+				return c.hasNight(t)
+			}
+			return c.TradingDay(t)
+		case sec >= p.start:
+			return c.hasNight(t)
+		case sec < p.end:
+			// 跨零点的夜盘, 归属前一天晚上
+			return c.hasNight(t.AddDate(0, 0, -1))
+		}
+	}
+	return false
+}
+
+// 是否为交易日, 周末与节假日休市
+func (c *Calendar) TradingDay(t time.Time) bool {
+	t = t.In(c.location)
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return false
+	}
+	return !c.holidays[t.Format(dateLayout)]
+}
+
+// 当天晚上是否有夜盘, 当天与下一个工作日都交易时才有夜盘
+func (c *Calendar) hasNight(t time.Time) bool {
+	if !c.TradingDay(t) {
+		return false
+	}
+	next := t.AddDate(0, 0, 1)
+	for next.Weekday() == time.Saturday || next.Weekday() == time.Sunday {
+		next = next.AddDate(0, 0, 1)
+	}
+	return c.TradingDay(next)
+}
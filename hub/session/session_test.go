@@ -0,0 +1,96 @@
+package session
+
+import (
+	"bitcoin-kline/config"
+	"testing"
+	"time"
+)
+
+var location = time.FixedZone("UTC+8", 8*3600)
+
+func TestInSession(t *testing.T) {
+	c, err := NewCalendar("09:00-10:15,10:30-11:30,13:30-15:00,21:00-01:00", "2020-01-01,2020-01-24~2020-01-31", location)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		time   string
+		expect bool
+	}{
+		{"2019-12-13 08:59:59", false},
+		{"2019-12-13 09:00:00", true},
+		{"2019-12-13 10:14:59", true},
+		{"2019-12-13 10:15:00", false}, // 小节休息
+		{"2019-12-13 12:00:00", false},
+		{"2019-12-13 14:59:59", true},
+		{"2019-12-13 15:00:00", false},
+		{"2019-12-13 21:00:00", true}, // 周五夜盘
+		{"2019-12-14 00:30:00", true}, // 周五夜盘跨零点
+		{"2019-12-14 01:00:00", false},
+		{"2019-12-14 10:00:00", false}, // 周六
+		{"2019-12-15 21:30:00", false}, // 周日晚无夜盘
+		{"2019-12-31 10:00:00", true},
+		{"2019-12-31 21:30:00", false}, // 元旦前一晚无夜盘
+		{"2020-01-01 10:00:00", false}, // 元旦
+		{"2020-01-23 21:30:00", false}, // 春节前一晚无夜盘
+		{"2020-01-27 10:00:00", false}, // 春节
+		{"2020-02-03 09:30:00", true},
+	}
+	for _, item := range cases {
+		tm, _ := time.ParseInLocation("2006-01-02 15:04:05", item.time, location)
+		if got := c.InSession(tm); got != item.expect {
+			t.Errorf("%s: expect %v, got %v", item.time, item.expect, got)
+		}
+	}
+
+	// 不同时区的时间按交易所时区判断
+	if !c.InSession(time.Date(2019, 12, 13, 1, 30, 0, 0, time.UTC)) {
+		t.Error("09:30 UTC+8 should be in session")
+	}
+
+	var all *Calendar
+	if !all.InSession(time.Now()) {
+		t.Error("nil calendar should always be in session")
+	}
+}
+
+func TestNewCalendar(t *testing.T) {
+	for _, hours := range []string{"", "09:00", "09:00-09:00", "9点-10点"} {
+		if _, err := NewCalendar(hours, "", location); err == nil {
+			t.Errorf("hours %q should return error", hours)
+		}
+	}
+	if _, err := NewCalendar("09:00-15:00", "2020/01/01", location); err == nil {
+		t.Error("invalid holiday should return error")
+	}
+
+	c, err := NewCalendar("09:00-15:00", "2020-12-31~2021-01-01", location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.HasHolidays(2020) || !c.HasHolidays(2021) || c.HasHolidays(2022) {
+		t.Error("unexpected holiday years")
+	}
+}
+
+func TestInitSession(t *testing.T) {
+	coinType := config.SupportCoinTypes[0]
+	if err := config.LoadConfig("testdata/session.ini"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := InitSession(); err != nil {
+		t.Fatal(err)
+	}
+	c := Get(coinType)
+	if c == nil {
+		t.Fatal("calendar not loaded")
+	}
+	for _, date := range []string{"2020-01-01", "2020-01-02"} {
+		tm, _ := time.ParseInLocation("2006-01-02 15:04", date+" 10:00", location)
+		if c.InSession(tm) {
+			t.Errorf("%s should be holiday", date)
+		}
+	}
+}
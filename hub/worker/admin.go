@@ -0,0 +1,131 @@
+package worker
+
+import (
+	"bitcoin-kline/hub/provider"
+	"bitcoin-kline/logger"
+	"errors"
+	"sort"
+)
+
+// 运行时管理数据商, 无需重启即可启动、停止、暂停单个数据商
+// 变更在下一秒的聚合中生效; 暂停的数据商保持采集, 其数据被丢弃
+// 停止或暂停时清除该数据商的成交量状态, 恢复后首次出现不计增量
+
+var (
+	errProviderRunning    = errors.New("provider already running")
+	errProviderNotRunning = errors.New("provider not running")
+)
+
+type ProviderState struct {
+	Name   string `json:"name"`
+	Paused bool   `json:"paused"`
+}
+
+// 运行中的数据商
+func (w *ProviderWorker) Providers() []ProviderState {
+	w.RLock()
+	defer w.RUnlock()
+
+	list := make([]ProviderState, 0, len(w.providers))
+	for name := range w.providers {
+		list = append(list, ProviderState{Name: name, Paused: w.paused[name]})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+func (w *ProviderWorker) providerNames() []string {
+	names := make([]string, 0)
+	for _, state := range w.Providers() {
+		names = append(names, state.Name)
+	}
+	return names
+}
+
+// 按配置创建并启动数据商
+func (w *ProviderWorker) StartProvider(name string) error {
+	w.adminLock.Lock()
+	defer w.adminLock.Unlock()
+
+	if w.running(name) {
+		return errProviderRunning
+	}
+	p, err := provider.New(name)
+	if err != nil {
+		return err
+	}
+	p.StartCollect()
+
+	w.Lock()
+	w.providers[name] = p
+	delete(w.paused, name)
+	w.Unlock()
+
+	logger.Info("ProviderWorker_start", name, "provider started")
+	return nil
+}
+
+// 停止数据商, 正在生成的k线不受影响
+func (w *ProviderWorker) StopProvider(name string) error {
+	w.adminLock.Lock()
+	defer w.adminLock.Unlock()
+
+	w.Lock()
+	p, ok := w.providers[name]
+	if ok {
+		delete(w.providers, name)
+		delete(w.paused, name)
+		w.resetVolume(name)
+	}
+	w.Unlock()
+	if !ok {
+		return errProviderNotRunning
+	}
+
+	// 从聚合中移除后再停止, 避免读取已关闭的数据商
+	p.Stop()
+	logger.Info("ProviderWorker_stop", name, "provider stopped")
+	return nil
+}
+
+// 暂停或恢复数据商参与聚合
+func (w *ProviderWorker) PauseProvider(name string, paused bool) error {
+	w.adminLock.Lock()
+	defer w.adminLock.Unlock()
+
+	w.Lock()
+	_, ok := w.providers[name]
+	if ok {
+		w.paused[name] = paused
+		w.resetVolume(name)
+	}
+	w.Unlock()
+	if !ok {
+		return errProviderNotRunning
+	}
+
+	if paused {
+		logger.Info("ProviderWorker_pause", name, "provider paused")
+	} else {
+		logger.Info("ProviderWorker_resume", name, "provider resumed")
+	}
+	return nil
+}
+
+func (w *ProviderWorker) running(name string) bool {
+	w.RLock()
+	defer w.RUnlock()
+	_, ok := w.providers[name]
+	return ok
+}
+
+// 清除数据商各币种的24小时成交量, 调用方持有锁
+func (w *ProviderWorker) resetVolume(name string) {
+	origin, ok := provider.NameOrigin(name)
+	if !ok {
+		return
+	}
+	for _, last := range w.lastVolume {
+		delete(last, origin)
+	}
+}
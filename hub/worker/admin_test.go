@@ -0,0 +1,68 @@
+package worker
+
+import (
+	"bitcoin-kline/constant"
+	"testing"
+	"time"
+)
+
+func TestProviderAdmin(t *testing.T) {
+	w := NewProviderWorker()
+	name := constant.ProviderMock
+	coinType := constant.CoinTypeETHUSDT
+
+	if err := w.StartProvider(name); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.StartProvider(name); err != errProviderRunning {
+		t.Fatalf("expect already running, got %v", err)
+	}
+	if !waitData(w, coinType) {
+		t.Fatal("no data after start")
+	}
+
+	// 暂停期间不参与聚合
+	if err := w.PauseProvider(name, true); err != nil {
+		t.Fatal(err)
+	}
+	if states := w.Providers(); len(states) != 1 || !states[0].Paused {
+		t.Fatalf("unexpected states %+v", states)
+	}
+	if waitData(w, coinType) {
+		t.Fatal("paused provider still aggregated")
+	}
+	if err := w.PauseProvider(name, false); err != nil {
+		t.Fatal(err)
+	}
+	if !waitData(w, coinType) {
+		t.Fatal("no data after resume")
+	}
+
+	if err := w.StopProvider(name); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.StopProvider(name); err != errProviderNotRunning {
+		t.Fatalf("expect not running, got %v", err)
+	}
+	if err := w.PauseProvider(name, true); err != errProviderNotRunning {
+		t.Fatalf("expect not running, got %v", err)
+	}
+	if len(w.Providers()) != 0 || len(w.readData(coinType)) != 0 {
+		t.Fatal("provider not removed")
+	}
+}
+
+// 3秒内是否读到数据
+func waitData(w *ProviderWorker, coinType string) bool {
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case <-time.After(100 * time.Millisecond):
+			if len(w.readData(coinType)) > 0 {
+				return true
+			}
+		case <-timeout:
+			return false
+		}
+	}
+}
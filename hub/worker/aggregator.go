@@ -0,0 +1,184 @@
+package worker
+
+import (
+	"bitcoin-kline/common"
+	"bitcoin-kline/config"
+	"bitcoin-kline/hub/provider"
+	"bitcoin-kline/logger"
+	"bitcoin-kline/model"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 聚合方式, 在[aggregate]中配置, [aggregate.<币种>]可单独配置:
+// method: mean 算术平均(默认), median 中位数, vwap 按24小时成交量加权, weighted 按配置的权重加权
+// weights: weighted方式各数据商的权重, 数据商:权重, 逗号分隔, 如 binance:5,huobi:3,zb:1, 未配置的数据商权重为1
+// vwap方式下未提供24小时成交量的数据商(如逐笔成交模式)权重为0, 全部为0时退化为算术平均
+// 注: smallnest/weighted为加权轮询选择, 每次只选出一个数据商, 不适用于加权平均
+
+const (
+	AggregateMean     = "mean"
+	AggregateMedian   = "median"
+	AggregateVwap     = "vwap"
+	AggregateWeighted = "weighted"
+
+	sectionAggregate = "aggregate"
+)
+
+// 聚合各数据商的某一价格字段, items不为空, 结果保留4位小数
+type Aggregator interface {
+	Aggregate(items []*model.Kline, field func(k *model.Kline) string) string
+}
+
+var (
+	aggregators    = make(map[string]Aggregator) // 币种 -> 聚合方式
+	aggregatorLock sync.RWMutex
+)
+
+func initAggregator() {
+	items := make(map[string]Aggregator)
+	for _, coinType := range config.SupportCoinTypes {
+		section := sectionAggregate + "." + coinType
+		method := config.GetConfig(section, "method")
+		if method == "" {
+			method = config.GetConfig(sectionAggregate, "method")
+		}
+		weights := config.GetConfig(section, "weights")
+		if weights == "" {
+			weights = config.GetConfig(sectionAggregate, "weights")
+		}
+
+		agg, err := NewAggregator(method, weights)
+		if err != nil {
+			logger.Error("providerworker_initAggregator", coinType, err.Error()+", use mean instead")
+			agg = &meanAggregator{}
+		}
+		items[coinType] = agg
+	}
+
+	aggregatorLock.Lock()
+	defer aggregatorLock.Unlock()
+	aggregators = items
+}
+
+// 按名称创建聚合方式, weights仅weighted方式使用
+func NewAggregator(method, weights string) (Aggregator, error) {
+	switch method {
+	case "", AggregateMean:
+		return &meanAggregator{}, nil
+	case AggregateMedian:
+		return &medianAggregator{}, nil
+	case AggregateVwap:
+		return &vwapAggregator{}, nil
+	case AggregateWeighted:
+		w, err := parseWeights(weights)
+		if err != nil {
+			return nil, err
+		}
+		return &weightedAggregator{weights: w}, nil
+	}
+	return nil, errors.New("aggregate method " + method + " not support")
+}
+
+// 数据商:权重, 逗号分隔
+func parseWeights(val string) (map[string]string, error) {
+	weights := make(map[string]string)
+	for _, item := range strings.Split(val, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		i := strings.LastIndex(item, ":")
+		if i <= 0 {
+			return nil, errors.New("weight " + item + " invalid")
+		}
+		weight := strings.TrimSpace(item[i+1:])
+		if n, err := strconv.ParseFloat(weight, 64); err != nil || n < 0 {
+			return nil, errors.New("weight " + item + " invalid")
+		}
+		weights[strings.TrimSpace(item[:i])] = weight
+	}
+	return weights, nil
+}
+
+// 币种的聚合方式, 未初始化时为算术平均
+func getAggregator(coinType string) Aggregator {
+	aggregatorLock.RLock()
+	defer aggregatorLock.RUnlock()
+	if agg, ok := aggregators[coinType]; ok {
+		return agg
+	}
+	return &meanAggregator{}
+}
+
+// 设置币种的聚合方式
+func SetAggregator(coinType string, agg Aggregator) {
+	aggregatorLock.Lock()
+	defer aggregatorLock.Unlock()
+	aggregators[coinType] = agg
+}
+
+// 算术平均
+type meanAggregator struct{}
+
+func (a *meanAggregator) Aggregate(items []*model.Kline, field func(k *model.Kline) string) string {
+	return average(items, field)
+}
+
+// 中位数, 偶数个时取中间两个的平均值
+type medianAggregator struct{}
+
+func (a *medianAggregator) Aggregate(items []*model.Kline, field func(k *model.Kline) string) string {
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		values = append(values, field(item))
+	}
+	sortValues(values)
+	val, _ := common.BcAdd(median(values), "0", 4)
+	return val
+}
+
+// 按各数据商24小时成交量(基础币种)加权
+type vwapAggregator struct{}
+
+func (a *vwapAggregator) Aggregate(items []*model.Kline, field func(k *model.Kline) string) string {
+	return weightedMean(items, field, func(k *model.Kline) string { return k.Volume24h })
+}
+
+// 按配置的数据商权重加权
+type weightedAggregator struct {
+	weights map[string]string // 数据商 -> 权重
+}
+
+func (a *weightedAggregator) Aggregate(items []*model.Kline, field func(k *model.Kline) string) string {
+	return weightedMean(items, field, func(k *model.Kline) string {
+		if weight, ok := a.weights[provider.OriginName(k.Origin)]; ok {
+			return weight
+		}
+		return "1"
+	})
+}
+
+// 加权平均, 权重为空或无效时按0计, 总权重为0时取算术平均
+func weightedMean(items []*model.Kline, field func(k *model.Kline) string, weight func(k *model.Kline) string) string {
+	sum, total := "0", "0"
+	for _, item := range items {
+		w := weight(item)
+		if ret, err := common.BcCmp(w, "0"); err != nil || ret <= 0 {
+			continue
+		}
+		val, err := common.BcMul(field(item), w, 18)
+		if err != nil {
+			continue
+		}
+		sum, _ = common.BcAdd(sum, val, 18)
+		total, _ = common.BcAdd(total, w, 18)
+	}
+	if ret, _ := common.BcCmp(total, "0"); ret <= 0 {
+		return average(items, field)
+	}
+	val, _ := common.BcDiv(sum, total, 4)
+	return val
+}
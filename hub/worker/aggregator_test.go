@@ -0,0 +1,53 @@
+package worker
+
+import (
+	"bitcoin-kline/constant"
+	"bitcoin-kline/model"
+	"testing"
+)
+
+func TestAggregator(t *testing.T) {
+	items := []*model.Kline{
+		{Origin: constant.ProviderBinanceOriginType, Close: "100", Volume24h: "300"},
+		{Origin: constant.ProviderHuoBiOriginType, Close: "101", Volume24h: "100"},
+		{Origin: constant.ProviderZBOriginType, Close: "110", Volume24h: ""},
+	}
+	closePrice := func(k *model.Kline) string { return k.Close }
+
+	cases := []struct {
+		method  string
+		weights string
+		expect  string
+	}{
+		{AggregateMean, "", "103.6667"},
+		{AggregateMedian, "", "101.0000"},
+		// 未提供24小时成交量的数据商权重为0
+		{AggregateVwap, "", "100.2500"},
+		// 未配置的数据商权重为1
+		{AggregateWeighted, "binance:5,huobi:3", "101.4444"},
+	}
+	for _, c := range cases {
+		agg, err := NewAggregator(c.method, c.weights)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if val := agg.Aggregate(items, closePrice); val != c.expect {
+			t.Fatalf("%s: expect %s, got %s", c.method, c.expect, val)
+		}
+	}
+
+	// 偶数个取中间两个的平均值, 总权重为0时取算术平均
+	if val := (&medianAggregator{}).Aggregate(items[:2], closePrice); val != "100.5000" {
+		t.Fatalf("unexpected median %s", val)
+	}
+	if val := (&vwapAggregator{}).Aggregate(items[2:], closePrice); val != "110.0000" {
+		t.Fatalf("unexpected vwap %s", val)
+	}
+
+	if _, err := NewAggregator("max", ""); err == nil {
+		t.Fatal("expect unsupported method error")
+	}
+	if _, err := NewAggregator(AggregateWeighted, "binance:-1"); err == nil {
+		t.Fatal("expect invalid weight error")
+	}
+}
@@ -0,0 +1,108 @@
+package worker
+
+import (
+	"bitcoin-kline/config"
+	"bitcoin-kline/hub/provider"
+	"bitcoin-kline/logger"
+	"bitcoin-kline/model"
+	"errors"
+	"sort"
+	"time"
+)
+
+// 补历史k线
+// 从各交易所拉取1分钟k线, 与实时数据相同的方式过滤异常值后取平均值
+// 按时间正序经saveKline2DB写入每个分时刻度, 开盘价取最早一条、收盘价取最后一条
+// 异常值过滤与聚合方式按[outlier]、[aggregate]配置, 与实时聚合一致
+// 注意upsert会累加成交量, 同一区间不要重复补数据
+
+const backfillBatchSize = 100 // 每次写库的分钟数
+
+// 补[start, end)区间的k线, names为空时使用所有提供历史接口的已启用数据商, 返回写入的分钟数
+func Backfill(coinType string, start, end int64, names []string) (int, error) {
+	if !supportCoinType(coinType) {
+		return 0, errors.New("coin type " + coinType + " not support")
+	}
+	if start >= end {
+		return 0, errors.New("start time must before end time")
+	}
+	if len(names) == 0 {
+		names = provider.Enabled()
+	}
+	// 补数据子命令不启动聚合服务, 需自行加载配置
+	initOutlierFilter()
+	initAggregator()
+
+	candles := make(map[int64][]*model.Kline)
+	for _, name := range names {
+		if !provider.HasHistory(name) {
+			continue
+		}
+		list, err := provider.FetchHistory(name, coinType, start, end)
+		if err != nil {
+			logger.Error("Backfill_fetchHistory", map[string]string{"provider": name, "coinType": coinType}, err.Error())
+			continue
+		}
+		for _, item := range list {
+			candles[item.CreateTime] = append(candles[item.CreateTime], item)
+		}
+	}
+	if len(candles) == 0 {
+		return 0, errors.New("no history data for " + coinType)
+	}
+
+	times := make([]int64, 0)
+	for t := range candles {
+		times = append(times, t)
+	}
+	sort.Slice(times, func(i, j int) bool {
+		return times[i] < times[j]
+	})
+
+	written := 0
+	items := make([]model.Kline, 0)
+	for i, t := range times {
+		kline := fixCandle(coinType, t, candles[t])
+		items = append(items, scaleKlines(*kline)...)
+
+		if (i+1)%backfillBatchSize == 0 || i == len(times)-1 {
+			if err := saveKline2DB(items); err != nil {
+				return written, err
+			}
+			written = i + 1
+			items = items[:0]
+		}
+	}
+
+	return written, nil
+}
+
+func supportCoinType(coinType string) bool {
+	for _, val := range config.SupportCoinTypes {
+		if val == coinType {
+			return true
+		}
+	}
+	return false
+}
+
+// 聚合同一分钟各数据商的k线
+func fixCandle(coinType string, createTime int64, items []*model.Kline) *model.Kline {
+	afterFilter := getOutlierFilter(coinType).Filter(items, "")
+	agg := getAggregator(coinType)
+	closePrice := agg.Aggregate(afterFilter, func(k *model.Kline) string { return k.Close })
+
+	return &model.Kline{
+		CoinType:    coinType,
+		High:        agg.Aggregate(afterFilter, func(k *model.Kline) string { return k.High }),
+		Low:         agg.Aggregate(afterFilter, func(k *model.Kline) string { return k.Low }),
+		Open:        agg.Aggregate(afterFilter, func(k *model.Kline) string { return k.Open }),
+		Close:       closePrice,
+		CreateTime:  createTime,
+		UpdateTime:  time.Now().Unix(),
+		TimeScale:   "1",
+		Origin:      1,
+		OriginPrice: closePrice,
+		Volume:      sum(afterFilter, func(k *model.Kline) string { return k.Volume }),
+	}
+}
@@ -0,0 +1,49 @@
+package worker
+
+import (
+	"bitcoin-kline/hub/provider"
+	"bitcoin-kline/model"
+	"sort"
+)
+
+// 聚合k线的构成: 本秒读到的全部数据商报价, 标记是否参与聚合及原因
+// items为读到的报价, candidates为未过期的报价(mid模式下价格已替换为中间价), included为过滤异常值后参与聚合的报价
+func constituents(kline *model.Kline, items, candidates, included []*model.Kline, ages map[int]int64) []model.Constituent {
+	reasons := make(map[int]string)
+	for _, item := range items {
+		reasons[item.Origin] = model.ReasonStale
+	}
+	prices := make(map[int]string)
+	for _, item := range candidates {
+		reasons[item.Origin] = model.ReasonOutlier
+		prices[item.Origin] = item.Close
+	}
+	for _, item := range included {
+		reasons[item.Origin] = ""
+	}
+
+	result := make([]model.Constituent, 0, len(items))
+	for _, item := range items {
+		price, ok := prices[item.Origin]
+		if !ok {
+			price = item.Close
+		}
+		volume := item.Volume24h
+		if item.Trades > 0 || volume == "" {
+			volume = item.Volume
+		}
+		result = append(result, model.Constituent{
+			CoinType:   kline.CoinType,
+			CreateTime: kline.CreateTime,
+			Provider:   provider.OriginName(item.Origin),
+			Origin:     item.Origin,
+			Price:      price,
+			Volume:     volume,
+			Age:        ages[item.Origin],
+			Included:   reasons[item.Origin] == "",
+			Reason:     reasons[item.Origin],
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Provider < result[j].Provider })
+	return result
+}
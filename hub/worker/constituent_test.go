@@ -0,0 +1,43 @@
+package worker
+
+import (
+	"bitcoin-kline/constant"
+	"bitcoin-kline/model"
+	"testing"
+)
+
+func TestConstituents(t *testing.T) {
+	items := []*model.Kline{
+		{Origin: constant.ProviderBinanceOriginType, Close: "100", Volume24h: "300"},
+		{Origin: constant.ProviderHuoBiOriginType, Close: "101", Volume24h: "100"},
+		{Origin: constant.ProviderOkexOriginType, Close: "150", Volume24h: "50"},
+		{Origin: constant.ProviderZBOriginType, Close: "99", Volume: "0.5", Trades: 3},
+	}
+	// zb报价过期, okex为异常值, mid模式下binance以中间价参与聚合
+	mid := items[0].Copy()
+	mid.Close = "100.5"
+	candidates := []*model.Kline{&mid, items[1], items[2]}
+	included := []*model.Kline{&mid, items[1]}
+	ages := map[int]int64{constant.ProviderBinanceOriginType: 300, constant.ProviderZBOriginType: 20000}
+
+	kline := &model.Kline{CoinType: constant.CoinTypeETHUSDT, CreateTime: 1576209600}
+	result := constituents(kline, items, candidates, included, ages)
+	expect := map[string]model.Constituent{
+		constant.ProviderBinance: {Price: "100.5", Volume: "300", Age: 300, Included: true},
+		constant.ProviderHuoBi:   {Price: "101", Volume: "100", Included: true},
+		constant.ProviderOkex:    {Price: "150", Volume: "50", Reason: model.ReasonOutlier},
+		constant.ProviderZB:      {Price: "99", Volume: "0.5", Age: 20000, Reason: model.ReasonStale},
+	}
+	if len(result) != len(expect) {
+		t.Fatalf("unexpected constituents %+v", result)
+	}
+	for _, item := range result {
+		e := expect[item.Provider]
+		if item.Price != e.Price || item.Volume != e.Volume || item.Age != e.Age || item.Included != e.Included || item.Reason != e.Reason {
+			t.Fatalf("%s: expect %+v, got %+v", item.Provider, e, item)
+		}
+		if item.CoinType != kline.CoinType || item.CreateTime != kline.CreateTime {
+			t.Fatalf("unexpected kline info %+v", item)
+		}
+	}
+}
@@ -3,11 +3,10 @@ package worker
 import (
 	"bitcoin-kline/common"
 	"bitcoin-kline/config"
+	"bitcoin-kline/hub/session"
 	"bitcoin-kline/logger"
 	"bitcoin-kline/model"
 	"fmt"
-	"math/rand"
-	"strconv"
 	"strings"
 	"time"
 )
@@ -17,16 +16,29 @@ type DbWorker struct {
 	exited         chan bool // 确认结束命令管道
 }
 
-const DefaultDbChanSize = 1024
+const (
+	DefaultDbChanSize    = 1024
+	constituentCacheSize = 600 // 审计记录缓存条数
+	defaultAuditKeepDays = 30
+)
 
 var (
-	klineDbChan chan model.Kline
-	klineCache  []model.Kline
+	klineDbChan      chan model.Kline
+	klineCache       []model.Kline
+	constituentCache []model.Constituent
+	auditKeepDays    int
 )
 
 func InitDbWorker() {
 	klineDbChan = make(chan model.Kline, DefaultDbChanSize)
 	klineCache = make([]model.Kline, 0)
+	constituentCache = make([]model.Constituent, 0)
+
+	// 聚合构成审计记录保留天数, 配置在[audit]的keep_days项
+	auditKeepDays = config.GetConfigInt("audit", "keep_days")
+	if auditKeepDays <= 0 {
+		auditKeepDays = defaultAuditKeepDays
+	}
 }
 
 func NewDbWorker() *DbWorker {
@@ -53,24 +65,23 @@ func (w *DbWorker) workLoop() {
 	for {
 		select {
 		case kline := <-klineDbChan:
-			// 随机生成成交量
-			kline.Volume = strconv.Itoa(rand.Intn(100))
+			// 交易时段外不生成k线
+			if !session.Get(kline.CoinType).InSession(time.Unix(kline.CreateTime, 0)) {
+				break
+			}
 
 			// 保存秒级数据
 			if err := saveTick(kline); err != nil {
 				logger.Error("DbWorker_saveTicker", err, "DbWorker saveTick err")
 			}
 
-			// 构造分时数据并保存
-			scaleItems := make([]model.Kline, 0)
-			for scale, val := range config.TimeScaleMap {
-				item := kline.Copy()
-				item.TimeScale = scale
-				item.CreateTime = item.CreateTime - item.CreateTime%int64(val)
-
-				scaleItems = append(scaleItems, item)
+			// 保存聚合构成审计记录
+			if err := saveConstituents(kline.Constituents); err != nil {
+				logger.Error("DbWorker_saveConstituents", err, "DbWorker saveConstituents err")
 			}
-			if err := saveKline2DB(scaleItems); err != nil {
+
+			// 构造分时数据并保存
+			if err := saveKline2DB(scaleKlines(kline)); err != nil {
 				logger.Error("DbWorker_saveKline2DB", err, "DbWorker saveKline2DB err")
 			}
 
@@ -78,6 +89,9 @@ func (w *DbWorker) workLoop() {
 			if err := deleteOldTick(); err != nil {
 				logger.Error("DbWorker_deleteOldTicker", err, "DbWorker deleteOldTick err")
 			}
+			if err := deleteOldConstituents(); err != nil {
+				logger.Error("DbWorker_deleteOldConstituents", err, "DbWorker deleteOldConstituents err")
+			}
 			// todo
 			timer.Reset(time.Hour * 6)
 
@@ -85,6 +99,9 @@ func (w *DbWorker) workLoop() {
 			if err := flushTickCache2DB(); err != nil {
 				logger.Error("DbWorker_flushTicker", err, "DbWorker flushTicker to db err")
 			}
+			if err := flushConstituents2DB(); err != nil {
+				logger.Error("DbWorker_flushConstituents", err, "DbWorker flushConstituents to db err")
+			}
 			goto EXIT
 		}
 	}
@@ -92,6 +109,19 @@ EXIT:
 	w.exited <- true
 }
 
+// 按各分时刻度构造k线, 写库时同一刻度内的成交量累加
+func scaleKlines(kline model.Kline) []model.Kline {
+	items := make([]model.Kline, 0, len(config.TimeScaleMap))
+	for scale, val := range config.TimeScaleMap {
+		item := kline.Copy()
+		item.TimeScale = scale
+		item.CreateTime = item.CreateTime - item.CreateTime%int64(val)
+
+		items = append(items, item)
+	}
+	return items
+}
+
 // 批量将kline数据写入或更新到数据库
 func saveKline2DB(items []model.Kline) error {
 	db := common.MustGetDB("kline")
@@ -161,3 +191,53 @@ func deleteOldTick() error {
 
 	return err
 }
+
+func saveConstituents(items []model.Constituent) error {
+	constituentCache = append(constituentCache, items...)
+	if len(constituentCache) < constituentCacheSize {
+		return nil
+	}
+
+	return flushConstituents2DB()
+}
+
+func flushConstituents2DB() error {
+	if len(constituentCache) == 0 {
+		return nil
+	}
+	db := common.MustGetDB("kline")
+
+	sql := "insert into kline_constituent (coinType, createTime, provider, origin, price, volume, age, included, reason) values "
+	values := []string{}
+	for _, item := range constituentCache {
+		included := 0
+		if item.Included {
+			included = 1
+		}
+		values = append(values, fmt.Sprintf("('%s', %v, '%s', %d, '%s', '%s', %v, %d, '%s')",
+			item.CoinType, item.CreateTime, item.Provider, item.Origin, item.Price, item.Volume, item.Age, included, item.Reason))
+	}
+	sql += strings.Join(values, ",")
+	err := db.Exec(sql).Error
+	if err != nil {
+		logger.Error("DbWorker_saveConstituents2DB", sql, "saveConstituents sql err")
+		// 数据库持续不可用时只保留最近的记录
+		if len(constituentCache) > constituentCacheSize*10 {
+			constituentCache = append(constituentCache[:0], constituentCache[len(constituentCache)-constituentCacheSize*10:]...)
+		}
+		return err
+	}
+	constituentCache = constituentCache[:0]
+	return nil
+}
+
+func deleteOldConstituents() error {
+	db := common.MustGetDB("kline")
+	sql := fmt.Sprintf("delete from kline_constituent where createTime < %v", time.Now().Unix()-int64(auditKeepDays)*86400)
+	err := db.Exec(sql).Error
+	if err != nil {
+		logger.Error("DbWorker_deleteOldConstituents", sql, "deleteOldConstituents sql err")
+	}
+
+	return err
+}
@@ -0,0 +1,119 @@
+package worker
+
+import (
+	"bitcoin-kline/config"
+	"bitcoin-kline/hub/provider"
+	"bitcoin-kline/logger"
+	"bitcoin-kline/model"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 报价时效与时钟偏差检测
+// 报价时间优先使用交易所时间, 交易所未提供时使用本地接收时间, 超过freshness秒的报价不参与聚合
+// 交易所时间与本地接收时间相差超过max_skew秒时告警; 多数数据商偏差一致时视为本地时钟偏差, 并按偏差修正报价时间
+// 配置在[provider]的freshness、max_skew项
+
+const (
+	defaultFreshness = 10 // 秒
+	defaultMaxSkew   = 2  // 秒
+	minSkewQuorum    = 3  // 判断本地时钟偏差最少需要的数据商数
+	skewWarnInterval = time.Minute
+)
+
+var (
+	freshness  int64                        // 毫秒
+	maxSkew    int64                        // 毫秒
+	skewWarned = make(map[string]time.Time) // 告警对象 -> 最近告警时间
+	skewLock   sync.Mutex
+)
+
+func initFreshness() {
+	freshness = defaultFreshness * 1000
+	if n := config.GetConfigInt("provider", "freshness"); n > 0 {
+		freshness = int64(n) * 1000
+	}
+	maxSkew = defaultMaxSkew * 1000
+	if n := config.GetConfigInt("provider", "max_skew"); n > 0 {
+		maxSkew = int64(n) * 1000
+	}
+}
+
+// 丢弃过期报价, now为本地时间 毫秒, ages为各数据来源报价的时长 毫秒
+func filterStale(coinType string, items []*model.Kline, now int64) (result []*model.Kline, ages map[int]int64) {
+	offset := checkSkew(coinType, items)
+
+	result = make([]*model.Kline, 0, len(items))
+	ages = make(map[int]int64)
+	for _, item := range items {
+		quoteTime := item.ReceiveTime
+		if item.ExchangeTime > 0 {
+			quoteTime = item.ExchangeTime + offset
+		}
+		if quoteTime > 0 {
+			ages[item.Origin] = now - quoteTime
+		}
+		if quoteTime > 0 && now-quoteTime > freshness {
+			logger.Error("providerworker_filterStale", item,
+				fmt.Sprintf("provider:%s, coinType:%s, quote is %dms old", provider.OriginName(item.Origin), coinType, now-quoteTime))
+			continue
+		}
+		result = append(result, item)
+	}
+	return result, ages
+}
+
+// 检测时钟偏差(本地接收时间-交易所时间), 返回本地时钟相对交易所的偏差 毫秒, 未检测到本地时钟偏差时为0
+func checkSkew(coinType string, items []*model.Kline) int64 {
+	skews := make([]int64, 0, len(items))
+	for _, item := range items {
+		if item.ExchangeTime > 0 && item.ReceiveTime > 0 {
+			skews = append(skews, item.ReceiveTime-item.ExchangeTime)
+		}
+	}
+	if len(skews) == 0 {
+		return 0
+	}
+
+	// 多数数据商偏差一致时为本地时钟偏差
+	var offset int64
+	if len(skews) >= minSkewQuorum {
+		sorted := append([]int64{}, skews...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		if median := sorted[len(sorted)/2]; abs(median) > maxSkew {
+			offset = median
+			warnSkew("local", fmt.Sprintf("coinType:%s, local clock skew %dms against exchanges", coinType, median))
+		}
+	}
+
+	for _, item := range items {
+		if item.ExchangeTime == 0 || item.ReceiveTime == 0 {
+			continue
+		}
+		name := provider.OriginName(item.Origin)
+		if skew := item.ReceiveTime - item.ExchangeTime - offset; abs(skew) > maxSkew {
+			warnSkew(name, fmt.Sprintf("provider:%s, coinType:%s, exchange clock skew %dms", name, coinType, skew))
+		}
+	}
+	return offset
+}
+
+// 同一对象每分钟最多告警一次
+func warnSkew(key string, msg string) {
+	skewLock.Lock()
+	defer skewLock.Unlock()
+	if last, ok := skewWarned[key]; ok && time.Since(last) < skewWarnInterval {
+		return
+	}
+	skewWarned[key] = time.Now()
+	logger.Error("providerworker_clockSkew", key, msg)
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,53 @@
+package worker
+
+import (
+	"bitcoin-kline/constant"
+	"bitcoin-kline/model"
+	"testing"
+)
+
+func TestFilterStale(t *testing.T) {
+	initFreshness()
+	now := int64(1576209600000)
+	coinType := constant.CoinTypeETHUSDT
+
+	items := []*model.Kline{
+		{Close: "1", ReceiveTime: now, ExchangeTime: now - 500},
+		{Close: "2", ReceiveTime: now, ExchangeTime: now - 30000}, // 交易所行情过期
+		{Close: "3", ReceiveTime: now - 20000},                    // 管道中积压过期
+		{Close: "4", ReceiveTime: now - 1000},                     // 交易所未提供时间
+	}
+	result, _ := filterStale(coinType, items, now)
+	if len(result) != 2 || result[0].Close != "1" || result[1].Close != "4" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
+
+func TestCheckSkew(t *testing.T) {
+	initFreshness()
+	now := int64(1576209600000)
+	coinType := constant.CoinTypeETHUSDT
+
+	// 单个交易所时钟偏差不修正
+	items := []*model.Kline{
+		{ReceiveTime: now, ExchangeTime: now - 100},
+		{ReceiveTime: now, ExchangeTime: now - 200},
+		{ReceiveTime: now, ExchangeTime: now + 8000},
+	}
+	if offset := checkSkew(coinType, items); offset != 0 {
+		t.Fatalf("expect offset 0, got %d", offset)
+	}
+
+	// 本地时钟快15秒, 报价时间按偏差修正后不丢弃
+	items = []*model.Kline{
+		{Close: "1", ReceiveTime: now, ExchangeTime: now - 15100},
+		{Close: "2", ReceiveTime: now, ExchangeTime: now - 15000},
+		{Close: "3", ReceiveTime: now, ExchangeTime: now - 14900},
+	}
+	if offset := checkSkew(coinType, items); offset != 15000 {
+		t.Fatalf("expect offset 15000, got %d", offset)
+	}
+	if result, _ := filterStale(coinType, items, now); len(result) != 3 {
+		t.Fatalf("expect 3 items, got %d", len(result))
+	}
+}
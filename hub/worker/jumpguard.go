@@ -0,0 +1,206 @@
+package worker
+
+import (
+	"bitcoin-kline/common"
+	"bitcoin-kline/config"
+	"bitcoin-kline/logger"
+	"bitcoin-kline/model"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// 价格跳变保护
+// 多家交易所同时出现错误报价时异常值过滤无法识别, 聚合价格会直接写库并推送
+// 聚合价格相对近期共识价格(window秒内已发布价格的中位数)变动超过percent时暂缓发布,
+// 同方向的跳变持续confirm秒后确认为真实行情, 暂缓的k线一并发布; 期间回落至阈值内则丢弃暂缓的k线
+// 暂缓、确认、丢弃均推送告警事件, 在[guard]中配置, [guard.<币种>]可单独配置:
+// percent: 变动比例阈值, 为小数而非百分数(0.05即5%), 默认0.05, 需在(0, 1)内, 无效时使用默认值
+// window: 共识价格的时间窗口, 单位秒, 默认60, 0为关闭
+// confirm: 确认跳变需要的秒数, 默认3
+
+const (
+	AlertJumpHold    = "jump_hold"    // 疑似跳变, 暂缓发布
+	AlertJumpConfirm = "jump_confirm" // 跳变持续, 确认发布
+	AlertJumpReject  = "jump_reject"  // 价格回落, 丢弃暂缓的k线
+
+	sectionGuard   = "guard"
+	defaultJump    = "0.05"
+	defaultWindow  = 60
+	defaultConfirm = 3
+)
+
+// 告警事件, 经mq推送
+type Alert struct {
+	Type      string `json:"type"`
+	CoinType  string `json:"coinType"`
+	Price     string `json:"price"`     // 最新聚合价格
+	Reference string `json:"reference"` // 近期共识价格
+	Change    string `json:"change"`    // 变动比例
+	Ticks     int    `json:"ticks"`     // 暂缓的k线数
+	Time      int64  `json:"time"`
+}
+
+// 告警管道, mq未启动时告警只记录日志
+var alertMqChan = make(chan *Alert, DefaultMqChanSize)
+
+type pricePoint struct {
+	price string
+	time  int64
+}
+
+// 单个币种的跳变保护, 仅在该币种的聚合协程中使用
+type jumpGuard struct {
+	coinType string
+	percent  string
+	window   int64
+	confirm  int
+
+	history   []pricePoint   // window内已发布的价格
+	held      []*model.Kline // 暂缓发布的k线
+	reference string         // 暂缓时的共识价格
+	direction int            // 暂缓的跳变方向
+}
+
+// 按配置创建币种的跳变保护
+func loadJumpGuard(coinType string) *jumpGuard {
+	section := sectionGuard + "." + coinType
+	conf := func(key string) string {
+		if val := config.GetConfig(section, key); val != "" {
+			return val
+		}
+		return config.GetConfig(sectionGuard, key)
+	}
+
+	percent := conf("percent")
+	if percent == "" {
+		percent = defaultJump
+	} else if !validJump(percent) {
+		logger.Error("providerworker_loadJumpGuard", coinType, "guard percent "+percent+" invalid, use "+defaultJump+" instead")
+		percent = defaultJump
+	}
+	window, err := strconv.Atoi(conf("window"))
+	if err != nil {
+		window = defaultWindow
+	}
+	confirm, err := strconv.Atoi(conf("confirm"))
+	if err != nil {
+		confirm = defaultConfirm
+	}
+	return newJumpGuard(coinType, percent, int64(window), confirm)
+}
+
+// 变动比例阈值需在(0, 1)内, 大于等于1相当于关闭保护
+func validJump(percent string) bool {
+	n, err := strconv.ParseFloat(percent, 64)
+	return err == nil && n > 0 && n < 1
+}
+
+func newJumpGuard(coinType, percent string, window int64, confirm int) *jumpGuard {
+	if confirm < 1 {
+		confirm = 1
+	}
+	return &jumpGuard{coinType: coinType, percent: percent, window: window, confirm: confirm}
+}
+
+func (g *jumpGuard) enabled() bool {
+	ret, err := common.BcCmp(g.percent, "0")
+	return err == nil && ret > 0 && g.window > 0
+}
+
+// 检查聚合k线, 返回可以发布的k线, 暂缓时返回空
+func (g *jumpGuard) check(kline *model.Kline) []*model.Kline {
+	if !g.enabled() {
+		return []*model.Kline{kline}
+	}
+	g.prune(kline.CreateTime)
+	if len(g.history) == 0 {
+		g.held = nil
+		g.accept(kline)
+		return []*model.Kline{kline}
+	}
+
+	reference := g.consensus()
+	diff, _ := common.BcSub(kline.Close, reference, 18)
+	change, _ := common.BcDiv(diff, reference, 6)
+	direction, _ := common.BcCmp(diff, "0")
+	distance, _ := common.BcAbsSub(kline.Close, reference, 18)
+	limit, _ := common.BcMul(reference, g.percent, 18)
+
+	if ret, _ := common.BcCmp(distance, limit); ret <= 0 {
+		if len(g.held) > 0 {
+			g.alert(AlertJumpReject, kline, change)
+			g.held = nil
+		}
+		g.accept(kline)
+		return []*model.Kline{kline}
+	}
+
+	// 反方向跳变视为新的疑似跳变
+	if len(g.held) > 0 && direction != g.direction {
+		g.alert(AlertJumpReject, kline, change)
+		g.held = nil
+	}
+	if len(g.held) == 0 {
+		g.reference = reference
+		g.direction = direction
+	}
+	g.held = append(g.held, kline)
+	if len(g.held) < g.confirm {
+		if len(g.held) == 1 {
+			g.alert(AlertJumpHold, kline, change)
+		}
+		return nil
+	}
+
+	// 跳变持续, 以新价格重建共识
+	g.alert(AlertJumpConfirm, kline, change)
+	released := g.held
+	g.held = nil
+	g.history = g.history[:0]
+	for _, item := range released {
+		g.accept(item)
+	}
+	return released
+}
+
+func (g *jumpGuard) accept(kline *model.Kline) {
+	g.history = append(g.history, pricePoint{price: kline.Close, time: kline.CreateTime})
+}
+
+// 移除window之外的价格
+func (g *jumpGuard) prune(now int64) {
+	i := 0
+	for i < len(g.history) && g.history[i].time <= now-g.window {
+		i++
+	}
+	g.history = g.history[i:]
+}
+
+// 近期共识价格, window内已发布价格的中位数
+func (g *jumpGuard) consensus() string {
+	values := make([]string, 0, len(g.history))
+	for _, point := range g.history {
+		values = append(values, point.price)
+	}
+	sortValues(values)
+	return median(values)
+}
+
+func (g *jumpGuard) alert(typ string, kline *model.Kline, change string) {
+	alert := &Alert{
+		Type:      typ,
+		CoinType:  g.coinType,
+		Price:     kline.Close,
+		Reference: g.reference,
+		Change:    change,
+		Ticks:     len(g.held),
+		Time:      time.Now().Unix(),
+	}
+	logger.Error("providerworker_jumpGuard", alert, fmt.Sprintf("coinType:%s, price:%s, reference:%s, change:%s, %s", g.coinType, alert.Price, alert.Reference, change, typ))
+
+	select {
+	case alertMqChan <- alert:
+	default:
+	}
+}
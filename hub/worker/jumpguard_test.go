@@ -0,0 +1,89 @@
+package worker
+
+import (
+	"bitcoin-kline/config"
+	"bitcoin-kline/constant"
+	"bitcoin-kline/model"
+	"testing"
+)
+
+func TestJumpGuard(t *testing.T) {
+	coinType := constant.CoinTypeETHUSDT
+	g := newJumpGuard(coinType, "0.05", 60, 3)
+	drainAlerts()
+
+	steps := []struct {
+		close    string
+		released int
+		alert    string
+	}{
+		{"100", 1, ""},
+		{"101", 1, ""},
+		// 单秒错误报价, 回落后丢弃
+		{"120", 0, AlertJumpHold},
+		{"100.5", 1, AlertJumpReject},
+		// 持续的跳变在第3秒确认并一并发布
+		{"90", 0, AlertJumpHold},
+		{"89", 0, ""},
+		{"89.5", 3, AlertJumpConfirm},
+		// 以新价格为共识
+		{"89.8", 1, ""},
+	}
+	for i, step := range steps {
+		released := g.check(&model.Kline{CoinType: coinType, Close: step.close, CreateTime: int64(1000 + i)})
+		if len(released) != step.released {
+			t.Fatalf("step %d: expect %d released, got %d", i, step.released, len(released))
+		}
+		alert := ""
+		select {
+		case item := <-alertMqChan:
+			alert = item.Type
+		default:
+		}
+		if alert != step.alert {
+			t.Fatalf("step %d: expect alert %q, got %q", i, step.alert, alert)
+		}
+	}
+
+	// 超出时间窗口的价格不再作为共识
+	if released := g.check(&model.Kline{CoinType: coinType, Close: "120", CreateTime: 2000}); len(released) != 1 {
+		t.Fatal("expect released after window")
+	}
+
+	// 阈值为0时关闭
+	g = newJumpGuard(coinType, "0", 60, 3)
+	for _, price := range []string{"100", "200"} {
+		if released := g.check(&model.Kline{Close: price, CreateTime: 1000}); len(released) != 1 {
+			t.Fatal("disabled guard should not hold")
+		}
+	}
+}
+
+func drainAlerts() {
+	for {
+		select {
+		case <-alertMqChan:
+		default:
+			return
+		}
+	}
+}
+
+func TestLoadJumpGuard(t *testing.T) {
+	if err := config.LoadConfig("testdata/guard.ini"); err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string]string{
+		constant.CoinTypeETHUSDT: defaultJump,
+		constant.CoinTypeBTCUSDT: defaultJump,
+		constant.CoinTypeRUCNY:   defaultJump,
+		constant.CoinTypeCUCNY:   defaultJump,
+		constant.CoinTypeMCNY:    "0.1",
+	}
+	for coinType, percent := range expect {
+		g := loadJumpGuard(coinType)
+		if g.percent != percent || !g.enabled() {
+			t.Fatalf("%s: expect percent %s, got %s", coinType, percent, g.percent)
+		}
+	}
+}
@@ -104,6 +104,30 @@ func (w *MqWorker) pushLoop() {
 			bytes, _ := json.Marshal(msgBody)
 			pushMq(event, string(bytes))
 
+		case alert := <-alertMqChan:
+			event := constant.MqEventTypeAlert + alert.CoinType
+			msgBody := struct {
+				EventType string `json:"eventType"`
+				Data      *Alert `json:"data"`
+			}{
+				EventType: event,
+				Data:      alert,
+			}
+			bytes, _ := json.Marshal(msgBody)
+			pushMq(event, string(bytes))
+
+		case status := <-statusMqChan:
+			event := constant.MqEventTypeStatus + status.CoinType
+			msgBody := struct {
+				EventType string         `json:"eventType"`
+				Data      *QualityStatus `json:"data"`
+			}{
+				EventType: event,
+				Data:      status,
+			}
+			bytes, _ := json.Marshal(msgBody)
+			pushMq(event, string(bytes))
+
 		case <-w.breakMainLogic:
 			return
 
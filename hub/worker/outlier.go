@@ -0,0 +1,270 @@
+package worker
+
+import (
+	"bitcoin-kline/common"
+	"bitcoin-kline/config"
+	"bitcoin-kline/hub/provider"
+	"bitcoin-kline/logger"
+	"bitcoin-kline/model"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// 异常报价过滤, 在[outlier]中配置, [outlier.<币种>]可单独配置:
+// method: iqr 箱线图法(默认), mad 中位数绝对偏差法, percent 偏离中位数的比例, none 不过滤
+// threshold: iqr为四分位距的倍数(默认1.5), mad为标准化绝对偏差的倍数(默认3), percent为偏离比例(默认0.03)
+// percent: 数据商较少时改用偏离比例过滤的阈值, 默认0.03
+// iqr至少需要4家、mad至少需要3家报价, 不足时按偏离中位数的比例过滤;
+// 只有1、2家时无法判断哪家异常, 以上一秒的聚合价格为参照; 全部报价都被判定为异常时不过滤, 避免行情剧烈波动时停止出价
+
+const (
+	OutlierIQR     = "iqr"
+	OutlierMAD     = "mad"
+	OutlierPercent = "percent"
+	OutlierNone    = "none"
+
+	sectionOutlier   = "outlier"
+	defaultIQRK      = "1.5"
+	defaultMADK      = "3"
+	defaultPercent   = "0.03"
+	madScale         = "1.4826" // 正态分布下绝对偏差中位数与标准差的换算系数
+	minIQRProviders  = 4
+	minMADProviders  = 3
+	minMedianSamples = 3
+)
+
+// 过滤异常报价, ref为上一秒的聚合价格, 没有时为空
+type OutlierFilter interface {
+	Filter(items []*model.Kline, ref string) []*model.Kline
+}
+
+var (
+	outlierFilters    = make(map[string]OutlierFilter) // 币种 -> 过滤方式
+	outlierFilterLock sync.RWMutex
+)
+
+func initOutlierFilter() {
+	items := make(map[string]OutlierFilter)
+	for _, coinType := range config.SupportCoinTypes {
+		section := sectionOutlier + "." + coinType
+		conf := func(key string) string {
+			if val := config.GetConfig(section, key); val != "" {
+				return val
+			}
+			return config.GetConfig(sectionOutlier, key)
+		}
+
+		filter, err := NewOutlierFilter(conf("method"), conf("threshold"), conf("percent"))
+		if err != nil {
+			logger.Error("providerworker_initOutlierFilter", coinType, err.Error()+", use iqr instead")
+			filter, _ = NewOutlierFilter(OutlierIQR, "", "")
+		}
+		items[coinType] = filter
+	}
+
+	outlierFilterLock.Lock()
+	defer outlierFilterLock.Unlock()
+	outlierFilters = items
+}
+
+// 按名称创建过滤方式, threshold、percent为空时使用默认值
+func NewOutlierFilter(method, threshold, percent string) (OutlierFilter, error) {
+	if percent == "" {
+		percent = defaultPercent
+	}
+	if !positive(percent) {
+		return nil, errors.New("outlier percent " + percent + " invalid")
+	}
+	fallback := &percentFilter{percent: percent}
+
+	if threshold != "" && !positive(threshold) {
+		return nil, errors.New("outlier threshold " + threshold + " invalid")
+	}
+	switch method {
+	case "", OutlierIQR:
+		if threshold == "" {
+			threshold = defaultIQRK
+		}
+		return &iqrFilter{k: threshold, fallback: fallback}, nil
+	case OutlierMAD:
+		if threshold == "" {
+			threshold = defaultMADK
+		}
+		return &madFilter{k: threshold, fallback: fallback}, nil
+	case OutlierPercent:
+		if threshold != "" {
+			fallback.percent = threshold
+		}
+		return fallback, nil
+	case OutlierNone:
+		return &noneFilter{}, nil
+	}
+	return nil, errors.New("outlier method " + method + " not support")
+}
+
+func positive(val string) bool {
+	n, err := strconv.ParseFloat(val, 64)
+	return err == nil && n > 0
+}
+
+// 币种的过滤方式, 未初始化时为iqr
+func getOutlierFilter(coinType string) OutlierFilter {
+	outlierFilterLock.RLock()
+	defer outlierFilterLock.RUnlock()
+	if filter, ok := outlierFilters[coinType]; ok {
+		return filter
+	}
+	filter, _ := NewOutlierFilter(OutlierIQR, "", "")
+	return filter
+}
+
+// 设置币种的过滤方式
+func SetOutlierFilter(coinType string, filter OutlierFilter) {
+	outlierFilterLock.Lock()
+	defer outlierFilterLock.Unlock()
+	outlierFilters[coinType] = filter
+}
+
+// 箱线图法, 超出[Q1-k*IQR, Q3+k*IQR]的报价视为异常, 四分位数按线性插值计算
+// 多数报价相同(四分位距为0)时按偏离比例过滤
+// https://baike.baidu.com/item/%E7%AE%B1%E5%BC%8F%E5%9B%BE
+type iqrFilter struct {
+	k        string
+	fallback *percentFilter
+}
+
+func (f *iqrFilter) Filter(items []*model.Kline, ref string) []*model.Kline {
+	if len(items) < minIQRProviders {
+		return f.fallback.Filter(items, ref)
+	}
+	values := sortedCloses(items)
+	q1 := quantile(values, 0.25)
+	q3 := quantile(values, 0.75)
+	iqr, _ := common.BcSub(q3, q1, 18)
+	if ret, _ := common.BcCmp(iqr, "0"); ret <= 0 {
+		return f.fallback.filterAround(items, median(values))
+	}
+	width, _ := common.BcMul(f.k, iqr, 18)
+	min, _ := common.BcSub(q1, width, 18)
+	max, _ := common.BcAdd(q3, width, 18)
+	return keepBetween(items, min, max, fmt.Sprintf("iqr Q1:%s, Q3:%s", q1, q3))
+}
+
+// 中位数绝对偏差法, 偏离中位数超过k倍标准化绝对偏差的报价视为异常
+// 多数报价相同(绝对偏差中位数为0)时按偏离比例过滤
+type madFilter struct {
+	k        string
+	fallback *percentFilter
+}
+
+func (f *madFilter) Filter(items []*model.Kline, ref string) []*model.Kline {
+	if len(items) < minMADProviders {
+		return f.fallback.Filter(items, ref)
+	}
+	values := sortedCloses(items)
+	center := median(values)
+	devs := make([]string, 0, len(values))
+	for _, val := range values {
+		dev, _ := common.BcAbsSub(val, center, 18)
+		devs = append(devs, dev)
+	}
+	sortValues(devs)
+	mad, _ := common.BcMul(median(devs), madScale, 18)
+	if ret, _ := common.BcCmp(mad, "0"); ret <= 0 {
+		return f.fallback.filterAround(items, center)
+	}
+	width, _ := common.BcMul(f.k, mad, 18)
+	min, _ := common.BcSub(center, width, 18)
+	max, _ := common.BcAdd(center, width, 18)
+	return keepBetween(items, min, max, fmt.Sprintf("mad median:%s, mad:%s", center, mad))
+}
+
+// 偏离中位数超过一定比例的报价视为异常, 不足3家时以上一秒的聚合价格为参照
+type percentFilter struct {
+	percent string
+}
+
+func (f *percentFilter) Filter(items []*model.Kline, ref string) []*model.Kline {
+	if len(items) >= minMedianSamples {
+		return f.filterAround(items, median(sortedCloses(items)))
+	}
+	if ref == "" {
+		return items
+	}
+	return f.filterAround(items, ref)
+}
+
+func (f *percentFilter) filterAround(items []*model.Kline, center string) []*model.Kline {
+	width, _ := common.BcMul(center, f.percent, 18)
+	min, _ := common.BcSub(center, width, 18)
+	max, _ := common.BcAdd(center, width, 18)
+	return keepBetween(items, min, max, fmt.Sprintf("percent center:%s, percent:%s", center, f.percent))
+}
+
+type noneFilter struct{}
+
+func (f *noneFilter) Filter(items []*model.Kline, ref string) []*model.Kline {
+	return items
+}
+
+// 保留收盘价在[min, max]内的报价, 全部超出时不过滤
+func keepBetween(items []*model.Kline, min, max, detail string) []*model.Kline {
+	result := make([]*model.Kline, 0, len(items))
+	for _, item := range items {
+		ret1, _ := common.BcCmp(item.Close, min)
+		ret2, _ := common.BcCmp(item.Close, max)
+		if ret1 < 0 || ret2 > 0 {
+			logger.Error("providerworker_filterOutliers", item,
+				fmt.Sprintf("provider:%s, close:%s, %s, min:%s, max:%s", provider.OriginName(item.Origin), item.Close, detail, min, max))
+			continue
+		}
+		result = append(result, item)
+	}
+	if len(result) == 0 {
+		return items
+	}
+	return result
+}
+
+// 从小到大排列的收盘价
+func sortedCloses(items []*model.Kline) []string {
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		values = append(values, item.Close)
+	}
+	sortValues(values)
+	return values
+}
+
+func sortValues(values []string) {
+	sort.Slice(values, func(i, j int) bool {
+		ret, _ := common.BcCmp(values[i], values[j])
+		return ret < 0
+	})
+}
+
+// 已排序数值的分位数, 按线性插值计算
+func quantile(values []string, p float64) string {
+	pos := p * float64(len(values)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	diff, _ := common.BcSub(values[hi], values[lo], 18)
+	frac, _ := common.BcMul(diff, strconv.FormatFloat(pos-float64(lo), 'f', -1, 64), 18)
+	val, _ := common.BcAdd(values[lo], frac, 18)
+	return val
+}
+
+// 已排序数值的中位数, 偶数个时取中间两个的平均值
+func median(values []string) string {
+	mid := len(values) / 2
+	if len(values)%2 == 1 {
+		return values[mid]
+	}
+	sum, _ := common.BcAdd(values[mid-1], values[mid], 18)
+	val, _ := common.BcDiv(sum, "2", 18)
+	return val
+}
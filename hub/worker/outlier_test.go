@@ -0,0 +1,70 @@
+package worker
+
+import (
+	"bitcoin-kline/model"
+	"testing"
+)
+
+func klines(closes ...string) []*model.Kline {
+	items := make([]*model.Kline, 0, len(closes))
+	for i, val := range closes {
+		items = append(items, &model.Kline{Origin: i + 1, Close: val})
+	}
+	return items
+}
+
+func closes(items []*model.Kline) []string {
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		values = append(values, item.Close)
+	}
+	return values
+}
+
+func TestOutlierFilter(t *testing.T) {
+	if q := quantile([]string{"1", "2", "3", "4"}, 0.25); q != "1.750000000000000000" {
+		t.Fatalf("unexpected quantile %s", q)
+	}
+
+	cases := []struct {
+		method string
+		items  []*model.Kline
+		ref    string
+		expect int
+	}{
+		{OutlierIQR, klines("100", "100.5", "101", "99.5", "100.2", "130"), "", 5},
+		// 不足4家时按偏离中位数的比例过滤
+		{OutlierIQR, klines("100", "101", "150"), "", 2},
+		{OutlierMAD, klines("100", "101", "150"), "", 2},
+		// 多数报价相同
+		{OutlierMAD, klines("100", "100", "100", "100.01", "150"), "", 4},
+		{OutlierIQR, klines("100", "100", "100", "100.01", "150"), "", 4},
+		{OutlierPercent, klines("100", "102", "104"), "", 3},
+		// 2家时以上一秒的聚合价格为参照, 没有参照时不过滤
+		{OutlierPercent, klines("100", "120"), "101", 1},
+		{OutlierMAD, klines("100", "120"), "", 2},
+		// 全部异常时不过滤
+		{OutlierPercent, klines("200"), "100", 1},
+		{OutlierNone, klines("100", "101", "150"), "", 3},
+	}
+	for i, c := range cases {
+		filter, err := NewOutlierFilter(c.method, "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result := filter.Filter(c.items, c.ref); len(result) != c.expect {
+			t.Fatalf("case %d %s: expect %d items, got %v", i, c.method, c.expect, closes(result))
+		}
+	}
+
+	// 阈值可配置
+	filter, _ := NewOutlierFilter(OutlierPercent, "0.01", "")
+	if result := filter.Filter(klines("100", "102", "104"), ""); len(result) != 1 {
+		t.Fatalf("unexpected result %v", closes(result))
+	}
+	for _, args := range [][3]string{{"zscore", "", ""}, {OutlierIQR, "-1", ""}, {OutlierMAD, "", "abc"}} {
+		if _, err := NewOutlierFilter(args[0], args[1], args[2]); err == nil {
+			t.Fatalf("expect error for %v", args)
+		}
+	}
+}
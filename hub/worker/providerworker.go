@@ -3,32 +3,36 @@ package worker
 import (
 	"bitcoin-kline/common"
 	"bitcoin-kline/config"
-	"bitcoin-kline/constant"
 	"bitcoin-kline/hub/provider"
-	"bitcoin-kline/hub/provider/binance"
-	"bitcoin-kline/hub/provider/bitmax"
-	"bitcoin-kline/hub/provider/bitz"
-	"bitcoin-kline/hub/provider/gateio"
-	"bitcoin-kline/hub/provider/huobi"
-	"bitcoin-kline/hub/provider/mock"
-	"bitcoin-kline/hub/provider/okex"
-	"bitcoin-kline/hub/provider/sina"
-	"bitcoin-kline/hub/provider/zb"
-	"bitcoin-kline/logger"
+	"bitcoin-kline/hub/session"
 	"bitcoin-kline/model"
-	"fmt"
-	"math"
-	"sort"
+	"errors"
 	"strconv"
 	"sync"
 	"time"
+
+	// 注册数据商
+	_ "bitcoin-kline/hub/provider/binance"
+	_ "bitcoin-kline/hub/provider/bitmax"
+	_ "bitcoin-kline/hub/provider/bitz"
+	_ "bitcoin-kline/hub/provider/gateio"
+	_ "bitcoin-kline/hub/provider/generic"
+	_ "bitcoin-kline/hub/provider/huobi"
+	_ "bitcoin-kline/hub/provider/mock"
+	_ "bitcoin-kline/hub/provider/okex"
+	_ "bitcoin-kline/hub/provider/sina"
+	_ "bitcoin-kline/hub/provider/zb"
 )
 
 type ProviderWorker struct {
-	providers    map[string]provider.Provider
+	providers    map[string]provider.Provider // 运行中的数据商, 运行时增删见admin.go
+	paused       map[string]bool              // 暂停聚合的数据商
 	currentKline map[string]*model.Kline
+	lastVolume   map[string]map[int]string // 币种 -> 数据来源 -> 最近一次24小时成交量(交易所原始计量单位)
+	quality      map[string]*QualityStatus // 币种 -> 已确认的质量状态
 
-	breakMainLogic chan bool // 结束命令管道
+	breakMainLogic chan bool  // 结束命令管道
+	adminLock      sync.Mutex // 串行执行数据商的增删
 
 	sync.RWMutex
 	sync.WaitGroup
@@ -45,62 +49,47 @@ func InitProviderWorker() {
 		fixedDataChan[coinType] = make(chan *model.Kline)
 	}
 
-	providers = make([]string, 0)
-	if config.CURMODE == config.ENV_DEV {
-		providers = append(providers, constant.ProviderMock)
-		//providers = append(providers, constant.ProviderSina)
-	} else {
-		//providers = append(providers, constant.ProviderMock)
-		providers = append(providers, constant.ProviderZB)
-		providers = append(providers, constant.ProviderHuoBi)
-		providers = append(providers, constant.ProviderOkex)
-		providers = append(providers, constant.ProviderBitz)
-		providers = append(providers, constant.ProviderGateio)
-		providers = append(providers, constant.ProviderBinance)
-		providers = append(providers, constant.ProviderBitmax)
-		providers = append(providers, constant.ProviderBitmax)
-		//providers = append(providers, constant.ProviderSina) // 橡胶期货数据
-	}
+	// 启用的数据商见配置[provider]的enabled项
+	providers = provider.Enabled()
+	initFreshness()
+	initQuote()
+	initAggregator()
+	initOutlierFilter()
+	initQuorum()
 }
 
 func NewProviderWorker() *ProviderWorker {
 	p := &ProviderWorker{
 		providers:      make(map[string]provider.Provider),
+		paused:         make(map[string]bool),
 		currentKline:   make(map[string]*model.Kline),
+		lastVolume:     make(map[string]map[int]string),
+		quality:        make(map[string]*QualityStatus),
 		breakMainLogic: make(chan bool),
 	}
 	return p
 }
 
 func (w *ProviderWorker) Start() error {
+	if len(providers) == 0 {
+		return errors.New("no provider enabled")
+	}
+	started := make(map[string]provider.Provider)
 	for _, val := range providers {
-		switch val {
-		case constant.ProviderMock:
-			w.providers[val] = mock.NewProvider()
-		case constant.ProviderZB:
-			w.providers[val] = zb.NewZbProvider()
-		case constant.ProviderHuoBi:
-			w.providers[val] = huobi.NewProvider()
-		case constant.ProviderOkex:
-			w.providers[val] = okex.NewProvider()
-		case constant.ProviderBitz:
-			w.providers[val] = bitz.NewProvider()
-		case constant.ProviderGateio:
-			w.providers[val] = gateio.NewProvider()
-		case constant.ProviderBinance:
-			w.providers[val] = binance.NewProvider()
-		case constant.ProviderBitmax:
-			w.providers[val] = bitmax.NewProvider()
-		case constant.ProviderSina:
-			w.providers[val] = sina.NewProvider()
-
+		p, err := provider.New(val)
+		if err != nil {
+			return err
 		}
+		started[val] = p
 	}
 
 	// start collect data
-	for _, p := range w.providers {
+	w.Lock()
+	for name, p := range started {
+		w.providers[name] = p
 		p.StartCollect()
 	}
+	w.Unlock()
 
 	// 聚合修正多家供应商的数据
 	for _, coinType := range config.SupportCoinTypes {
@@ -116,9 +105,13 @@ func (w *ProviderWorker) Start() error {
 
 // 结束主逻辑
 func (w *ProviderWorker) Stop() {
+	w.adminLock.Lock()
+	defer w.adminLock.Unlock()
+	w.RLock()
 	for _, p := range w.providers {
 		p.Stop()
 	}
+	w.RUnlock()
 
 	close(w.breakMainLogic)
 	w.Wait()
@@ -133,19 +126,58 @@ func (w *ProviderWorker) fixDataLoop(params ...interface{}) {
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
 
+	opened := false // 当前交易时段是否已产出开盘k线
+	guard := loadJumpGuard(coinType)
+	tracker := newQualityTracker(coinType)
 	for {
 		select {
 		case <-ticker.C:
+			now := time.Now()
+			calendar := session.Get(coinType)
 			items := w.readData(coinType)
+
+			// 交易时段外丢弃数据商的过期报价
+			if !calendar.InSession(now) {
+				opened = false
+				break
+			}
 			item := w.fixData(coinType, items)
 
+			// 质量状态变化时推送状态事件, 无可用报价时为stale
+			quality := QualityStale
+			if item != nil {
+				quality = item.Quality
+			}
+			if status := tracker.update(quality, includedProviders(item), now.Unix()); status != nil {
+				w.setQuality(status)
+			}
+
+			// 收盘前最后一秒没有新报价时, 以最新价生成收盘k线
+			closing := !calendar.InSession(now.Add(time.Second))
+			if item == nil && closing && opened {
+				item = w.closeKline(coinType, now.Unix())
+			}
 			if item == nil {
 				break
 			}
 
-			select {
-			case fixedDataChan[coinType] <- item:
-			case <-w.breakMainLogic:
+			// 跳变保护, 疑似跳变的k线暂缓发布, 确认后一并发布
+			released := guard.check(item)
+			for i, item := range released {
+				if !opened {
+					item.Session = session.Open
+					opened = true
+				}
+				if closing && i == len(released)-1 {
+					item.Session = session.Close
+				}
+				w.setCurrentKline(item)
+
+				select {
+				case fixedDataChan[coinType] <- item:
+				case <-w.breakMainLogic:
+					return
+				}
 			}
 
 		case <-w.breakMainLogic:
@@ -154,12 +186,18 @@ func (w *ProviderWorker) fixDataLoop(params ...interface{}) {
 	}
 }
 
-// 从数据商管道读取数据
+// 从数据商管道读取数据, 暂停的数据商照常读取以免阻塞采集, 但不参与聚合
 func (w *ProviderWorker) readData(coinType string) []*model.Kline {
+	w.RLock()
+	defer w.RUnlock()
+
 	items := make([]*model.Kline, 0)
-	for _, p := range w.providers {
+	for name, p := range w.providers {
 		select {
 		case item := <-p.ReadChan(coinType):
+			if w.paused[name] {
+				break
+			}
 			items = append(items, item)
 			break
 		default:
@@ -169,6 +207,11 @@ func (w *ProviderWorker) readData(coinType string) []*model.Kline {
 	return items
 }
 
+// 数据商健康状态
+func (w *ProviderWorker) ProviderStatus() []provider.HealthStatus {
+	return provider.Health(w.providerNames()...)
+}
+
 func (w *ProviderWorker) setCurrentKline(kline *model.Kline) {
 	w.Lock()
 	defer w.Unlock()
@@ -181,33 +224,67 @@ func (w *ProviderWorker) getCurrentKline(coinType string) *model.Kline {
 	return w.currentKline[coinType]
 }
 
+// 以最新价构造收盘k线, 本秒无成交, 成交量为0, 开高低收均为最新价
+func (w *ProviderWorker) closeKline(coinType string, now int64) *model.Kline {
+	current := w.getCurrentKline(coinType)
+	if current == nil {
+		return nil
+	}
+	kline := current.Copy()
+	kline.CreateTime = now
+	kline.UpdateTime = now
+	kline.Open = kline.Close
+	kline.High = kline.Close
+	kline.Low = kline.Close
+	kline.Volume = "0"
+	kline.QuoteVolume = "0"
+	kline.Trades = 0
+	kline.Constituents = nil
+	kline.Quality = QualityStale
+	w.setCurrentKline(&kline)
+	return &kline
+}
+
 func (w *ProviderWorker) fixData(coinType string, items []*model.Kline) *model.Kline {
-	if len(items) == 0 {
+	// 丢弃过期报价
+	fresh, ages := filterStale(coinType, items, time.Now().UnixNano()/1e6)
+	if len(fresh) == 0 {
 		return nil
 	}
 
-	// 过滤异常值
-	afterFilter := filterOutliers(items)
-	prices := make([]map[string]string, 0)
-	priceSum := "0"
-	priceVol := "0"
+	// 过滤异常值, 过滤方式按币种配置, 见outlier.go; mid模式下按中间价过滤与聚合
+	var ref string
+	if current := w.getCurrentKline(coinType); current != nil {
+		ref = current.Close
+	}
+	candidates := priceItems(fresh)
+	afterFilter := getOutlierFilter(coinType).Filter(candidates, ref)
+
+	// 参与聚合的数据商是否满足quorum, 见quorum.go
+	quality := getQuorum(coinType).Check(afterFilter)
+
+	// 计算市场价格, 逐笔成交模式的数据商提供秒内真实的开高低价, 快照模式开高低收相同
+	// 聚合方式按币种配置, 见aggregator.go
+	agg := getAggregator(coinType)
+	marketPrice := agg.Aggregate(afterFilter, func(k *model.Kline) string { return k.Close })
+	open := agg.Aggregate(afterFilter, func(k *model.Kline) string { return k.Open })
+	high := agg.Aggregate(afterFilter, func(k *model.Kline) string { return k.High })
+	low := agg.Aggregate(afterFilter, func(k *model.Kline) string { return k.Low })
+
+	// 本秒成交量为各数据商成交量之和
+	vol, quoteVol := w.volumeIncrease(coinType, afterFilter)
+	trades := 0
 	for _, item := range afterFilter {
-		prices = append(prices, map[string]string{constant.ProviderOriginMap[item.Origin]: item.Close})
-		priceSum, _ = common.BcAdd(priceSum, item.Close, 18)
-		priceVol, _ = common.BcAdd(priceVol, item.Volume, 18)
+		trades += item.Trades
 	}
 
-	// 计算市场平均值
-	marketPrice, _ := common.BcDiv(priceSum, strconv.Itoa(len(afterFilter)), 4)
-	vol, _ := common.BcDiv(priceVol, strconv.Itoa(len(afterFilter)), 4)
-
 	// 构造kline
 	now := time.Now().Unix()
 	kline := &model.Kline{
 		CoinType:    coinType,
-		High:        marketPrice,
-		Low:         marketPrice,
-		Open:        marketPrice,
+		High:        high,
+		Low:         low,
+		Open:        open,
 		Close:       marketPrice,
 		CreateTime:  now,
 		UpdateTime:  now,
@@ -215,49 +292,37 @@ func (w *ProviderWorker) fixData(coinType string, items []*model.Kline) *model.K
 		Origin:      1,
 		OriginPrice: marketPrice,
 		Volume:      vol,
-	}
+		QuoteVolume: quoteVol,
+		Trades:      trades,
+		Quality:     quality,
 
-	w.setCurrentKline(kline)
+		Volume24h:      sum(afterFilter, func(k *model.Kline) string { return k.Volume24h }),
+		QuoteVolume24h: sum(afterFilter, func(k *model.Kline) string { return k.QuoteVolume24h }),
+	}
+	consolidateQuote(kline, afterFilter)
+	kline.Constituents = constituents(kline, items, candidates, afterFilter, ages)
 	return kline
 }
 
-// 虚盒法过滤异常值, 先从小到大排序
-// https://baike.baidu.com/item/%E7%AE%B1%E5%BC%8F%E5%9B%BE
-func filterOutliers(items []*model.Kline) []*model.Kline {
-	length := len(items)
-	if length < 4 {
-		return items
+// 各数据商某一字段的平均值, 保留4位小数
+func average(items []*model.Kline, field func(k *model.Kline) string) string {
+	sum := "0"
+	for _, item := range items {
+		sum, _ = common.BcAdd(sum, field(item), 18)
 	}
-	sort.Slice(items, func(i, j int) bool {
-		ret, _ := common.BcCmp(items[i].Close, items[j].Close)
-		return ret < 0
-	})
-
-	val1, _ := common.BcMul("0.25", items[int(math.Floor(float64((length)/4)))].Close, 18)
-	val2, _ := common.BcMul("0.75", items[int(math.Ceil(float64((length)/4)))].Close, 18)
-	q1, _ := common.BcAdd(val1, val2, 18)
-
-	val1, _ = common.BcMul("0.25", items[int(math.Floor(float64((length)*3/4)))].Close, 18)
-	val2, _ = common.BcMul("0.75", items[int(math.Ceil(float64((length)*3/4)))].Close, 18)
-	q3, _ := common.BcAdd(val1, val2, 18)
-
-	iqr, _ := common.BcSub(q3, q1, 18)
-
-	iqr15, _ := common.BcMul("1.5", iqr, 18)
-	max, _ := common.BcAdd(q3, iqr15, 18)
-	min, _ := common.BcSub(q1, iqr15, 18)
-
-	result := make([]*model.Kline, 0)
-	for i, item := range items {
-		ret1, _ := common.BcCmp(item.Close, min)
-		ret2, _ := common.BcCmp(item.Close, max)
-		if ret1 < 0 || ret2 > 0 {
-			logger.Error("providerworker_filterOutliers", items,
-				fmt.Sprintf("provider:%s, close:%s, Q1:%s, Q3:%s, min:%s, max:%s index:%d", constant.ProviderOriginMap[item.Origin], item.Close, q1, q3, min, max, i))
-			continue
+	val, _ := common.BcDiv(sum, strconv.Itoa(len(items)), 4)
+	return val
+}
+
+// 各数据商某一字段之和, 保留4位小数, 忽略空值
+func sum(items []*model.Kline, field func(k *model.Kline) string) string {
+	total := "0"
+	for _, item := range items {
+		if val := field(item); val != "" {
+			if ret, err := common.BcAdd(total, val, 4); err == nil {
+				total = ret
+			}
 		}
-		result = append(result, item)
 	}
-
-	return result
+	return total
 }
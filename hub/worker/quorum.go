@@ -0,0 +1,247 @@
+package worker
+
+import (
+	"bitcoin-kline/config"
+	"bitcoin-kline/hub/provider"
+	"bitcoin-kline/logger"
+	"bitcoin-kline/model"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 报价法定家数(quorum)与行情质量
+// 参与聚合(未过期且非异常)的数据商不足时聚合价格可靠性下降, 全部无报价时行情会静默中断
+// 在[quorum]中配置, [quorum.<币种>]可单独配置:
+// min: 参与聚合的数据商最少家数, 默认1
+// primary: 主要数据商, 逗号分隔, 如 binance,okex,huobi
+// min_primary: 参与聚合的主要数据商最少家数, 配置了primary时默认1
+// confirm: 质量状态持续的秒数, 达到后推送状态事件, 避免单秒缺少报价造成抖动, 默认3
+// 每秒k线带有质量状态: ok 满足quorum; degraded 不满足quorum但仍有报价, 照常发布; stale 无可用报价, 仅收盘时以最新价生成的k线为此状态
+// 质量状态变化时经mq推送状态事件, 无可用报价时不发布k线但推送stale事件
+
+const (
+	QualityOk       = "ok"
+	QualityDegraded = "degraded"
+	QualityStale    = "stale"
+
+	sectionQuorum        = "quorum"
+	defaultQuorumConfirm = 3
+)
+
+type Quorum struct {
+	Min        int      `json:"min"`
+	Primary    []string `json:"primary,omitempty"`
+	MinPrimary int      `json:"minPrimary,omitempty"`
+	Confirm    int      `json:"-"`
+}
+
+// 质量状态事件, 经mq推送
+type QualityStatus struct {
+	CoinType  string   `json:"coinType"`
+	Quality   string   `json:"quality"`
+	Previous  string   `json:"previous,omitempty"` // 变化前的状态, 启动后首次确认时为空
+	Providers []string `json:"providers"`          // 参与聚合的数据商
+	Quorum    *Quorum  `json:"quorum"`
+	Since     int64    `json:"since"` // 进入该状态的时间
+	Time      int64    `json:"time"`
+}
+
+var (
+	quorums    = make(map[string]*Quorum) // 币种 -> quorum
+	quorumLock sync.RWMutex
+
+	// 状态事件管道, mq未启动时只记录日志
+	statusMqChan = make(chan *QualityStatus, DefaultMqChanSize)
+)
+
+func initQuorum() {
+	items := make(map[string]*Quorum)
+	for _, coinType := range config.SupportCoinTypes {
+		section := sectionQuorum + "." + coinType
+		conf := func(key string) string {
+			if val := config.GetConfig(section, key); val != "" {
+				return val
+			}
+			return config.GetConfig(sectionQuorum, key)
+		}
+
+		q, err := NewQuorum(conf("min"), conf("primary"), conf("min_primary"), conf("confirm"))
+		if err != nil {
+			logger.Error("providerworker_initQuorum", coinType, err.Error()+", use min 1 instead")
+			q, _ = NewQuorum("", "", "", "")
+		}
+		items[coinType] = q
+	}
+
+	quorumLock.Lock()
+	defer quorumLock.Unlock()
+	quorums = items
+}
+
+// 创建quorum, 参数为空时使用默认值
+func NewQuorum(min, primary, minPrimary, confirm string) (*Quorum, error) {
+	q := &Quorum{Min: 1, Confirm: defaultQuorumConfirm}
+	if min != "" {
+		n, err := strconv.Atoi(min)
+		if err != nil || n < 1 {
+			return nil, errors.New("quorum min " + min + " invalid")
+		}
+		q.Min = n
+	}
+	for _, name := range strings.Split(primary, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			q.Primary = append(q.Primary, name)
+		}
+	}
+	if len(q.Primary) > 0 {
+		q.MinPrimary = 1
+	}
+	if minPrimary != "" {
+		n, err := strconv.Atoi(minPrimary)
+		if err != nil || n < 0 || n > len(q.Primary) {
+			return nil, errors.New("quorum min_primary " + minPrimary + " invalid")
+		}
+		q.MinPrimary = n
+	}
+	if confirm != "" {
+		n, err := strconv.Atoi(confirm)
+		if err != nil || n < 1 {
+			return nil, errors.New("quorum confirm " + confirm + " invalid")
+		}
+		q.Confirm = n
+	}
+	return q, nil
+}
+
+// 币种的quorum, 未初始化时为至少1家
+func getQuorum(coinType string) *Quorum {
+	quorumLock.RLock()
+	defer quorumLock.RUnlock()
+	if q, ok := quorums[coinType]; ok {
+		return q
+	}
+	q, _ := NewQuorum("", "", "", "")
+	return q
+}
+
+// 设置币种的quorum
+func SetQuorum(coinType string, q *Quorum) {
+	quorumLock.Lock()
+	defer quorumLock.Unlock()
+	quorums[coinType] = q
+}
+
+// 参与聚合的报价是否满足quorum, 返回质量状态
+func (q *Quorum) Check(items []*model.Kline) string {
+	if len(items) == 0 {
+		return QualityStale
+	}
+	if len(items) < q.Min {
+		return QualityDegraded
+	}
+	primary := 0
+	for _, item := range items {
+		name := provider.OriginName(item.Origin)
+		for _, val := range q.Primary {
+			if val == name {
+				primary++
+				break
+			}
+		}
+	}
+	if primary < q.MinPrimary {
+		return QualityDegraded
+	}
+	return QualityOk
+}
+
+// 单个币种的质量状态跟踪, 仅在该币种的聚合协程中使用
+type qualityTracker struct {
+	coinType string
+	current  string // 已确认的状态
+	pending  string // 待确认的状态
+	start    int64  // 进入待确认状态的时间
+	ticks    int    // 待确认状态持续的秒数
+}
+
+func newQualityTracker(coinType string) *qualityTracker {
+	return &qualityTracker{coinType: coinType}
+}
+
+// 记录本秒的质量状态, 新状态持续confirm秒后确认并推送状态事件, 返回确认的事件
+func (t *qualityTracker) update(quality string, providers []string, now int64) *QualityStatus {
+	if quality == t.current {
+		t.pending, t.ticks = "", 0
+		return nil
+	}
+	if quality != t.pending {
+		t.pending, t.start, t.ticks = quality, now, 0
+	}
+	t.ticks++
+	q := getQuorum(t.coinType)
+	if t.ticks < q.Confirm {
+		return nil
+	}
+
+	status := &QualityStatus{
+		CoinType:  t.coinType,
+		Quality:   quality,
+		Previous:  t.current,
+		Providers: providers,
+		Quorum:    q,
+		Since:     t.start,
+		Time:      now,
+	}
+	t.current = quality
+	t.pending, t.ticks = "", 0
+
+	msg := fmt.Sprintf("coinType:%s, quality:%s, previous:%s, providers:%v", t.coinType, quality, status.Previous, providers)
+	if quality == QualityOk {
+		logger.Info("providerworker_quorum", status, msg)
+	} else {
+		logger.Error("providerworker_quorum", status, msg)
+	}
+
+	select {
+	case statusMqChan <- status:
+	default:
+	}
+	return status
+}
+
+// 参与聚合的数据商
+func includedProviders(kline *model.Kline) []string {
+	names := make([]string, 0)
+	if kline == nil {
+		return names
+	}
+	for _, item := range kline.Constituents {
+		if item.Included {
+			names = append(names, item.Provider)
+		}
+	}
+	return names
+}
+
+// 各币种已确认的质量状态
+func (w *ProviderWorker) Quality() []*QualityStatus {
+	w.RLock()
+	defer w.RUnlock()
+
+	list := make([]*QualityStatus, 0, len(w.quality))
+	for _, coinType := range config.SupportCoinTypes {
+		if status, ok := w.quality[coinType]; ok {
+			list = append(list, status)
+		}
+	}
+	return list
+}
+
+func (w *ProviderWorker) setQuality(status *QualityStatus) {
+	w.Lock()
+	defer w.Unlock()
+	w.quality[status.CoinType] = status
+}
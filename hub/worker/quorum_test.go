@@ -0,0 +1,111 @@
+package worker
+
+import (
+	"bitcoin-kline/constant"
+	"bitcoin-kline/model"
+	"testing"
+)
+
+func TestQuorumCheck(t *testing.T) {
+	binance := &model.Kline{Origin: constant.ProviderBinanceOriginType}
+	huobi := &model.Kline{Origin: constant.ProviderHuoBiOriginType}
+	zb := &model.Kline{Origin: constant.ProviderZBOriginType}
+
+	// 至少3家
+	q, err := NewQuorum("3", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quality := q.Check([]*model.Kline{binance, huobi, zb}); quality != QualityOk {
+		t.Fatalf("expect ok, got %s", quality)
+	}
+	if quality := q.Check([]*model.Kline{binance, huobi}); quality != QualityDegraded {
+		t.Fatalf("expect degraded, got %s", quality)
+	}
+	if quality := q.Check(nil); quality != QualityStale {
+		t.Fatalf("expect stale, got %s", quality)
+	}
+
+	// 主要数据商中至少2家
+	q, err = NewQuorum("", constant.ProviderBinance+", "+constant.ProviderHuoBi+","+constant.ProviderOkex, "2", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quality := q.Check([]*model.Kline{binance, huobi}); quality != QualityOk {
+		t.Fatalf("expect ok, got %s", quality)
+	}
+	if quality := q.Check([]*model.Kline{binance, zb}); quality != QualityDegraded {
+		t.Fatalf("expect degraded, got %s", quality)
+	}
+
+	for _, args := range [][]string{{"0", "", "", ""}, {"", "binance", "2", ""}, {"", "", "", "0"}} {
+		if _, err := NewQuorum(args[0], args[1], args[2], args[3]); err == nil {
+			t.Fatalf("expect error for %v", args)
+		}
+	}
+}
+
+func TestQualityTracker(t *testing.T) {
+	coinType := constant.CoinTypeETHUSDT
+	SetQuorum(coinType, &Quorum{Min: 1, Confirm: 3})
+	defer initQuorum()
+	drainStatus()
+
+	tracker := newQualityTracker(coinType)
+	steps := []struct {
+		quality string
+		status  string
+	}{
+		// 启动后确认初始状态
+		{QualityOk, ""},
+		{QualityOk, ""},
+		{QualityOk, QualityOk},
+		// 单秒缺少报价不推送
+		{QualityStale, ""},
+		{QualityOk, ""},
+		// 持续无报价
+		{QualityStale, ""},
+		{QualityStale, ""},
+		{QualityStale, QualityStale},
+		{QualityStale, ""},
+		// 状态变化重新计数
+		{QualityDegraded, ""},
+		{QualityOk, ""},
+		{QualityOk, ""},
+		{QualityOk, QualityOk},
+	}
+	for i, step := range steps {
+		status := tracker.update(step.quality, nil, int64(1000+i))
+		quality := ""
+		if status != nil {
+			quality = status.Quality
+			if event := <-statusMqChan; event != status {
+				t.Fatalf("step %d: status not pushed", i)
+			}
+		}
+		if quality != step.status {
+			t.Fatalf("step %d: expect status %q, got %q", i, step.status, quality)
+		}
+	}
+
+	// 恢复事件记录之前的状态及进入时间
+	if status := tracker.update(QualityDegraded, nil, 2000); status != nil {
+		t.Fatal("expect no status")
+	}
+	tracker.update(QualityDegraded, nil, 2001)
+	status := tracker.update(QualityDegraded, nil, 2002)
+	if status == nil || status.Previous != QualityOk || status.Since != 2000 {
+		t.Fatalf("unexpected status %+v", status)
+	}
+	drainStatus()
+}
+
+func drainStatus() {
+	for {
+		select {
+		case <-statusMqChan:
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package worker
+
+import (
+	"bitcoin-kline/common"
+	"bitcoin-kline/config"
+	"bitcoin-kline/model"
+	"strconv"
+)
+
+// 买卖盘报价聚合
+// 聚合价格默认使用各数据商的最新成交价, [provider]配置price = mid时使用买一卖一中间价,
+// 避免成交稀少的交易所的成交价影响指数; 数据商未提供买卖价时仍使用最新成交价
+
+const (
+	PriceLast = "last" // 最新成交价
+	PriceMid  = "mid"  // 买一卖一中间价
+)
+
+var priceSource string
+
+func initQuote() {
+	priceSource = config.GetConfig("provider", "price")
+	if priceSource != PriceMid {
+		priceSource = PriceLast
+	}
+}
+
+// 买一卖一中间价, 买卖价缺失或倒挂时返回false
+func midPrice(item *model.Kline) (string, bool) {
+	if item.Bid == "" || item.Ask == "" {
+		return "", false
+	}
+	ret, err := common.BcCmp(item.Bid, item.Ask)
+	if err != nil || ret > 0 {
+		return "", false
+	}
+	sum, err := common.BcAdd(item.Bid, item.Ask, 18)
+	if err != nil {
+		return "", false
+	}
+	mid, err := common.BcDiv(sum, "2", 4)
+	if err != nil {
+		return "", false
+	}
+	return mid, true
+}
+
+// 按配置的价格来源生成参与聚合的报价, mid模式下快照报价的开高低收替换为中间价
+// 逐笔成交模式的k线不含买卖价, 仍使用成交价
+func priceItems(items []*model.Kline) []*model.Kline {
+	if priceSource != PriceMid {
+		return items
+	}
+	result := make([]*model.Kline, 0, len(items))
+	for _, item := range items {
+		mid, ok := midPrice(item)
+		if !ok {
+			result = append(result, item)
+			continue
+		}
+		kline := item.Copy()
+		kline.Open, kline.High, kline.Low, kline.Close = mid, mid, mid, mid
+		result = append(result, &kline)
+	}
+	return result
+}
+
+// 汇总各数据商的买卖盘: 最高买价、最低卖价及其数量, 价差为各数据商买卖价差的平均值
+func consolidateQuote(kline *model.Kline, items []*model.Kline) {
+	sum, count := "0", 0
+	for _, item := range items {
+		if _, ok := midPrice(item); !ok {
+			continue
+		}
+		if ret, _ := common.BcCmp(item.Bid, kline.Bid); kline.Bid == "" || ret > 0 {
+			kline.Bid, kline.BidSize = item.Bid, item.BidSize
+		}
+		if ret, _ := common.BcCmp(item.Ask, kline.Ask); kline.Ask == "" || ret < 0 {
+			kline.Ask, kline.AskSize = item.Ask, item.AskSize
+		}
+		spread, _ := common.BcSub(item.Ask, item.Bid, 18)
+		sum, _ = common.BcAdd(sum, spread, 18)
+		count++
+	}
+	if count > 0 {
+		kline.Spread, _ = common.BcDiv(sum, strconv.Itoa(count), 4)
+	}
+}
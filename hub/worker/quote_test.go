@@ -0,0 +1,48 @@
+package worker
+
+import (
+	"bitcoin-kline/model"
+	"testing"
+)
+
+func TestPriceItems(t *testing.T) {
+	items := []*model.Kline{
+		{Close: "201", Bid: "199.9", Ask: "200.1"},
+		{Close: "200", Bid: "200.2", Ask: "200.1"}, // 买卖价倒挂
+		{Close: "199"},
+	}
+
+	priceSource = PriceLast
+	if result := priceItems(items); result[0].Close != "201" {
+		t.Fatalf("last mode should keep close, got %s", result[0].Close)
+	}
+
+	priceSource = PriceMid
+	defer func() { priceSource = PriceLast }()
+	result := priceItems(items)
+	if result[0].Close != "200.0000" || result[1].Close != "200" || result[2].Close != "199" {
+		t.Fatalf("unexpected mid prices: %s %s %s", result[0].Close, result[1].Close, result[2].Close)
+	}
+	if items[0].Close != "201" {
+		t.Fatal("source items should not be modified")
+	}
+}
+
+func TestConsolidateQuote(t *testing.T) {
+	items := []*model.Kline{
+		{Bid: "199.9", BidSize: "1", Ask: "200.1", AskSize: "2"},
+		{Bid: "200", BidSize: "3", Ask: "200.3", AskSize: "4"},
+		{Close: "199"},
+	}
+	kline := &model.Kline{}
+	consolidateQuote(kline, items)
+	if kline.Bid != "200" || kline.BidSize != "3" || kline.Ask != "200.1" || kline.AskSize != "2" || kline.Spread != "0.2500" {
+		t.Fatalf("unexpected quote: %+v", kline)
+	}
+
+	kline = &model.Kline{}
+	consolidateQuote(kline, []*model.Kline{{Close: "199"}})
+	if kline.Bid != "" || kline.Spread != "" {
+		t.Fatalf("quote without bid/ask should be empty: %+v", kline)
+	}
+}
@@ -0,0 +1,69 @@
+package worker
+
+import (
+	"bitcoin-kline/common"
+	"bitcoin-kline/hub/provider"
+	"bitcoin-kline/model"
+)
+
+// 成交量聚合
+// 数据商上报24小时滚动成交量, 按声明的计量单位换算为基础币种与报价币种后跨数据商求和, 作为综合24小时成交量
+// 每秒成交量为各数据商24小时成交量的增量之和, 分钟等k线的成交量由每秒成交量累加得到
+// 增量按交易所原始计量单位计算后再以当前价格换算, 避免以价格换算的24小时成交量随价格波动产生虚假增量
+// 滚动窗口移出成交时24小时成交量会下降, 增量计为0; 降幅超过一半视为交易所重新统计(如期货按交易日累计), 增量为当前值
+// 逐笔成交模式的数据商直接提供本秒成交量, 不参与24小时成交量的统计
+
+// 各数据商本秒成交量之和, 快照模式为24小时成交量相对上一次的增量, 首次出现的数据商不计增量
+func (w *ProviderWorker) volumeIncrease(coinType string, items []*model.Kline) (string, string) {
+	w.Lock()
+	defer w.Unlock()
+
+	last, ok := w.lastVolume[coinType]
+	if !ok {
+		last = make(map[int]string)
+		w.lastVolume[coinType] = last
+	}
+
+	base, quote := "0", "0"
+	for _, item := range items {
+		if item.Trades > 0 {
+			base, _ = common.BcAdd(base, item.Volume, 4)
+			quote, _ = common.BcAdd(quote, item.QuoteVolume, 4)
+			continue
+		}
+		if item.Volume24h == "" {
+			continue
+		}
+		prev, ok := last[item.Origin]
+		last[item.Origin] = item.Volume
+		if !ok {
+			continue
+		}
+		incBase, incQuote := provider.NormalizeVolume(increase(prev, item.Volume), item.Close, item.VolumeUnit)
+		if incBase == "" {
+			continue
+		}
+		base, _ = common.BcAdd(base, incBase, 4)
+		quote, _ = common.BcAdd(quote, incQuote, 4)
+	}
+	return base, quote
+}
+
+// 24小时成交量的增量, 按交易所原始计量单位计算
+func increase(prev, cur string) string {
+	if prev == "" || cur == "" {
+		return "0"
+	}
+	diff, err := common.BcSub(cur, prev, 4)
+	if err != nil {
+		return "0"
+	}
+	if ret, _ := common.BcCmp(diff, "0"); ret >= 0 {
+		return diff
+	}
+	half, _ := common.BcDiv(prev, "2", 18)
+	if ret, _ := common.BcCmp(cur, half); ret < 0 {
+		return cur
+	}
+	return "0"
+}
@@ -0,0 +1,94 @@
+package worker
+
+import (
+	"bitcoin-kline/common"
+	"bitcoin-kline/constant"
+	"bitcoin-kline/hub/provider"
+	"bitcoin-kline/model"
+	"testing"
+)
+
+func TestVolumeIncrease(t *testing.T) {
+	w := NewProviderWorker()
+	coinType := constant.CoinTypeETHUSDT
+
+	steps := []struct {
+		items []*model.Kline
+		base  string
+		quote string
+	}{
+		// 首次出现不计增量
+		{[]*model.Kline{{Origin: 1, Close: "200", Volume: "100", Volume24h: "100"}}, "0", "0"},
+		{[]*model.Kline{
+			{Origin: 1, Close: "200", Volume: "102", Volume24h: "102"},
+			{Origin: 2, Close: "200", Volume: "10000", VolumeUnit: provider.VolumeQuote, Volume24h: "50"},
+		}, "2.0000", "400.0000"},
+		// 滚动窗口移出成交时不计负增量, 报价币种计量的增量按当前价格换算
+		{[]*model.Kline{
+			{Origin: 1, Close: "200", Volume: "101", Volume24h: "101"},
+			{Origin: 2, Close: "200", Volume: "10300", VolumeUnit: provider.VolumeQuote, Volume24h: "51.5"},
+		}, "1.5000", "300.0000"},
+		// 24小时成交量不变时价格变化不产生增量
+		{[]*model.Kline{
+			{Origin: 1, Close: "199.98", Volume: "101", Volume24h: "101"},
+			{Origin: 2, Close: "199.98", Volume: "10300", VolumeUnit: provider.VolumeQuote, Volume24h: "51.5051"},
+		}, "0.0000", "0.0000"},
+		// 交易所重新统计
+		{[]*model.Kline{{Origin: 2, Close: "200", Volume: "600", VolumeUnit: provider.VolumeQuote, Volume24h: "3"}}, "3.0000", "600.0000"},
+		// 逐笔成交模式直接累加本秒成交量
+		{[]*model.Kline{
+			{Origin: 2, Close: "200", Volume: "700", VolumeUnit: provider.VolumeQuote, Volume24h: "3.5"},
+			{Origin: 3, Volume: "0.25", QuoteVolume: "50", Trades: 2},
+		}, "0.7500", "150.0000"},
+	}
+	for i, step := range steps {
+		base, quote := w.volumeIncrease(coinType, step.items)
+		if base != step.base || quote != step.quote {
+			t.Fatalf("step %d: expect %s %s, got %s %s", i, step.base, step.quote, base, quote)
+		}
+	}
+
+	if total := sum([]*model.Kline{{Volume24h: "1.5"}, {Volume24h: ""}, {Volume24h: "2"}}, func(k *model.Kline) string { return k.Volume24h }); total != "3.5000" {
+		t.Fatalf("unexpected sum %s", total)
+	}
+}
+
+// 收盘k线不重复计入最后一秒的成交量, 按写库时的累加方式计算分钟成交量
+func TestCloseKlineVolume(t *testing.T) {
+	w := NewProviderWorker()
+	coinType := constant.CoinTypeETHUSDT
+	minute := int64(1576209600)
+
+	stored := make(map[int64]string) // 分钟k线时间 -> 成交量
+	save := func(kline *model.Kline) {
+		for _, item := range scaleKlines(*kline) {
+			if item.TimeScale != "1" {
+				continue
+			}
+			if val, ok := stored[item.CreateTime]; ok {
+				stored[item.CreateTime], _ = common.BcAdd(val, item.Volume, 4)
+			} else {
+				stored[item.CreateTime] = item.Volume
+			}
+		}
+	}
+
+	for i, vol := range []string{"1.5", "2.5"} {
+		kline := &model.Kline{CoinType: coinType, Open: "99", High: "101", Low: "98", Close: "100",
+			CreateTime: minute + 57 + int64(i), Volume: vol, QuoteVolume: "150", Trades: 2}
+		w.setCurrentKline(kline)
+		save(kline)
+	}
+	closing := w.closeKline(coinType, minute+59)
+	save(closing)
+
+	if closing.Volume != "0" || closing.QuoteVolume != "0" || closing.Trades != 0 {
+		t.Fatalf("unexpected closing volume %+v", closing)
+	}
+	if closing.Open != "100" || closing.High != "100" || closing.Low != "100" || closing.Close != "100" {
+		t.Fatalf("unexpected closing price %+v", closing)
+	}
+	if vol := stored[minute]; vol != "4.0000" {
+		t.Fatalf("expect minute volume 4.0000, got %s", vol)
+	}
+}
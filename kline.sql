@@ -35,4 +35,20 @@ CREATE TABLE `tick_cache` (
      `volume` varchar(32)  NOT NULL DEFAULT '0' COMMENT '交易笔数',
      PRIMARY KEY (`id`) USING BTREE,
      UNIQUE KEY `coin_time_scale` (`coinType`,`timeScale`,`createTime`) USING BTREE
-) ENGINE=InnoDB COMMENT='外部实时报价数据';
\ No newline at end of file
+) ENGINE=InnoDB COMMENT='外部实时报价数据';
+
+DROP TABLE IF EXISTS `kline_constituent`;
+CREATE TABLE `kline_constituent` (
+     `id` bigint NOT NULL AUTO_INCREMENT COMMENT 'id',
+     `coinType` varchar(10) NOT NULL DEFAULT '' COMMENT '币种',
+     `createTime` bigint NOT NULL COMMENT '聚合k线时间',
+     `provider` varchar(32) NOT NULL DEFAULT '' COMMENT '数据商',
+     `origin` int NOT NULL COMMENT '数据来源',
+     `price` varchar(20) NOT NULL DEFAULT '' COMMENT '参与聚合的价格',
+     `volume` varchar(32) NOT NULL DEFAULT '' COMMENT '成交量',
+     `age` bigint NOT NULL DEFAULT '0' COMMENT '报价时长 毫秒',
+     `included` tinyint NOT NULL COMMENT '是否参与聚合：1:是，0：否',
+     `reason` varchar(20) NOT NULL DEFAULT '' COMMENT '未参与聚合的原因：stale 过期，outlier 异常值',
+     PRIMARY KEY (`id`) USING BTREE,
+     KEY `coin_time` (`coinType`,`createTime`) USING BTREE
+) ENGINE=InnoDB COMMENT='聚合k线构成审计';
@@ -4,11 +4,16 @@ import (
 	"bitcoin-kline/common"
 	"bitcoin-kline/config"
 	"bitcoin-kline/hub"
+	"bitcoin-kline/hub/worker"
 	"bitcoin-kline/logger"
 	"bitcoin-kline/router"
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"net/http"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/judwhite/go-svc/svc"
@@ -26,28 +31,11 @@ func (s *BaseServer) Init(env svc.Environment) error {
 		println(key, val)
 	}
 
-	// init log
-	logger.ConfigLogger(
-		config.CURMODE,
-		config.GetConfig("system", "app_name"),
-		config.GetConfig("logs", "dir"),
-		config.GetConfig("logs", "file_name"),
-		config.GetConfigInt("logs", "keep_days"),
-		config.GetConfigInt("logs", "rate_hours"),
-	)
+	initLogger()
 	println("logger init success")
 
-	// init mysql
-	dbInfo := config.GetSection("dbInfo")
-	for name, info := range dbInfo {
-		if err := common.AddDB(
-			name,
-			info,
-			config.GetConfigInt("mysql", "maxConn"),
-			config.GetConfigInt("mysql", "idleConn"),
-			time.Hour*time.Duration(config.GetConfigInt("mysql", "maxLeftTime"))); err != nil {
-			return err
-		}
+	if err := initMysql(); err != nil {
+		return err
 	}
 	println("mysql init success")
 
@@ -78,9 +66,10 @@ func (s *BaseServer) Init(env svc.Environment) error {
 }
 
 func (s *BaseServer) Start() error {
+	s.worker = hub.NewHub()
 	s.server = &http.Server{
 		Addr:    ":" + config.GetConfig("system", "http_listen_port"),
-		Handler: router.NewEngine(),
+		Handler: router.NewEngine(s.worker),
 	}
 	go func() {
 		if err := s.server.ListenAndServe(); err != nil {
@@ -91,7 +80,6 @@ func (s *BaseServer) Start() error {
 	}()
 	println("http service start")
 
-	s.worker = hub.NewHub()
 	if err := s.worker.Start(); err != nil {
 		panic(err)
 	}
@@ -122,7 +110,89 @@ func (s *BaseServer) Stop() error {
 	return nil
 }
 
+// init log
+func initLogger() {
+	logger.ConfigLogger(
+		config.CURMODE,
+		config.GetConfig("system", "app_name"),
+		config.GetConfig("logs", "dir"),
+		config.GetConfig("logs", "file_name"),
+		config.GetConfigInt("logs", "keep_days"),
+		config.GetConfigInt("logs", "rate_hours"),
+	)
+}
+
+// init mysql
+func initMysql() error {
+	dbInfo := config.GetSection("dbInfo")
+	for name, info := range dbInfo {
+		if err := common.AddDB(
+			name,
+			info,
+			config.GetConfigInt("mysql", "maxConn"),
+			config.GetConfigInt("mysql", "idleConn"),
+			time.Hour*time.Duration(config.GetConfigInt("mysql", "maxLeftTime"))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const backfillTimeLayout = "2006-01-02 15:04:05"
+
+// 补历史k线
+// ./kline backfill -coin ETH/USDT -start "2020-01-01 00:00:00" -end "2020-01-02 00:00:00" [-providers binance,okex]
+func runBackfill(args []string) error {
+	flags := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	coinType := flags.String("coin", "", "coin type, e.g. ETH/USDT")
+	start := flags.String("start", "", "start time, "+backfillTimeLayout)
+	end := flags.String("end", "", "end time (exclusive), "+backfillTimeLayout)
+	names := flags.String("providers", "", "comma separated providers, default enabled providers")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *coinType == "" || *start == "" || *end == "" {
+		flags.Usage()
+		return errors.New("coin, start and end are required")
+	}
+
+	startTime, err := time.ParseInLocation(backfillTimeLayout, *start, time.Local)
+	if err != nil {
+		return err
+	}
+	endTime, err := time.ParseInLocation(backfillTimeLayout, *end, time.Local)
+	if err != nil {
+		return err
+	}
+	providers := make([]string, 0)
+	for _, name := range strings.Split(*names, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			providers = append(providers, name)
+		}
+	}
+
+	config.InitConfig()
+	initLogger()
+	if err := initMysql(); err != nil {
+		return err
+	}
+	defer common.ReleaseMysqlDBPool()
+
+	n, err := worker.Backfill(*coinType, startTime.Unix(), endTime.Unix(), providers)
+	println(fmt.Sprintf("backfill %s %d minutes", *coinType, n))
+	return err
+}
+
 func main() {
+	// 补历史k线子命令
+	if len(os.Args) > 1 && os.Args[1] == "backfill" {
+		if err := runBackfill(os.Args[2:]); err != nil {
+			println(err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
 	if err := svc.Run(&BaseServer{}); err != nil {
 		println(err.Error())
 	}
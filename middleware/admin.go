@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const HeaderAdminToken = "X-Admin-Token"
+
+// 管理接口鉴权, 请求头X-Admin-Token需与配置的token一致
+// token未配置时管理接口关闭, 所有请求均拒绝
+func AdminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"code": 1,
+				"msg":  "admin disabled",
+			})
+			return
+		}
+		val := c.Request.Header.Get(HeaderAdminToken)
+		if subtle.ConstantTimeCompare([]byte(val), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"code": 1,
+				"msg":  "invalid admin token",
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAdminAuth(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	newEngine := func(token string) *gin.Engine {
+		engine := gin.New()
+		engine.POST("/admin/provider/:name/stop", AdminAuth(token), func(c *gin.Context) {
+			c.String(http.StatusOK, "stopped")
+		})
+		return engine
+	}
+	request := func(engine *gin.Engine, token string) int {
+		req := httptest.NewRequest(http.MethodPost, "/admin/provider/mock/stop", nil)
+		if token != "" {
+			req.Header.Set(HeaderAdminToken, token)
+		}
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	engine := newEngine("secret")
+	if code := request(engine, ""); code != http.StatusUnauthorized {
+		t.Fatalf("expect 401 without token, got %d", code)
+	}
+	if code := request(engine, "wrong"); code != http.StatusUnauthorized {
+		t.Fatalf("expect 401 with wrong token, got %d", code)
+	}
+	if code := request(engine, "secret"); code != http.StatusOK {
+		t.Fatalf("expect 200 with token, got %d", code)
+	}
+
+	// 未配置token时管理接口关闭
+	if code := request(newEngine(""), ""); code != http.StatusForbidden {
+		t.Fatalf("expect 403 when disabled, got %d", code)
+	}
+}
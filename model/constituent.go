@@ -0,0 +1,39 @@
+package model
+
+import (
+	"bitcoin-kline/common"
+)
+
+// 未参与聚合的原因
+const (
+	ReasonStale   = "stale"   // 报价过期
+	ReasonOutlier = "outlier" // 异常值
+)
+
+// 聚合k线中各数据商的报价及是否参与聚合, 随mq消息推送并写入审计表, 用于追溯发布价格的来源
+type Constituent struct {
+	Id         int64  `gorm:"column:id;primary_key;AUTO_INCREMENT" json:"-"`
+	CoinType   string `gorm:"column:coinType" json:"-"`
+	CreateTime int64  `gorm:"column:createTime" json:"-"` // 聚合k线时间
+	Provider   string `gorm:"column:provider" json:"provider"`
+	Origin     int    `gorm:"column:origin" json:"origin"`
+	Price      string `gorm:"column:price" json:"price"`             // 参与聚合的价格, mid模式为中间价
+	Volume     string `gorm:"column:volume" json:"volume"`           // 成交量 基础币种, 快照模式为24小时成交量, 逐笔成交模式为本秒成交量
+	Age        int64  `gorm:"column:age" json:"age"`                 // 报价时长 毫秒
+	Included   bool   `gorm:"column:included" json:"included"`       // 是否参与聚合
+	Reason     string `gorm:"column:reason" json:"reason,omitempty"` // 未参与聚合的原因
+}
+
+func (c *Constituent) TableName() string {
+	return "kline_constituent"
+}
+
+// 查询某一秒聚合k线的构成
+func GetConstituents(coinType string, createTime int64) []Constituent {
+	items := make([]Constituent, 0)
+	db := common.MustGetDB("kline")
+	if err := db.Where("coinType=? and createTime=?", coinType, createTime).Order("id").Find(&items).Error; err != nil {
+		return nil
+	}
+	return items
+}
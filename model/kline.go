@@ -6,18 +6,35 @@ import (
 )
 
 type Kline struct {
-	Id          int64  `gorm:"column:id;primary_key;AUTO_INCREMENT" json:"-"` // id
-	CoinType    string `gorm:"column:coinType" json:"coinType"`               // 币种
-	High        string `gorm:"column:high" json:"high"`                       // 最高报价
-	Low         string `gorm:"column:low" json:"low"`                         // 最低报价
-	Open        string `gorm:"column:open" json:"open"`                       // 开盘价
-	Close       string `gorm:"column:close" json:"close"`                     // 收盘价
-	CreateTime  int64  `gorm:"column:createTime" json:"time"`                 // 时间
-	UpdateTime  int64  `gorm:"column:updateTime" json:"-"`                    // 最后更新时间
-	TimeScale   string `gorm:"column:timeScale" json:"-"`                     // 分时图刻度
-	Origin      int    `gorm:"column:origin" json:"origin"`                   // 是否原始数据：1:是，0：否
-	OriginPrice string `gorm:"column:originPrice" json:"-"`                   // 原始报价 市场价
-	Volume      string `gorm:"column:volume" json:"volume"`                   // 24小时成交量
+	Id           int64  `gorm:"column:id;primary_key;AUTO_INCREMENT" json:"-"` // id
+	CoinType     string `gorm:"column:coinType" json:"coinType"`               // 币种
+	High         string `gorm:"column:high" json:"high"`                       // 最高报价
+	Low          string `gorm:"column:low" json:"low"`                         // 最低报价
+	Open         string `gorm:"column:open" json:"open"`                       // 开盘价
+	Close        string `gorm:"column:close" json:"close"`                     // 收盘价
+	CreateTime   int64  `gorm:"column:createTime" json:"time"`                 // 时间
+	UpdateTime   int64  `gorm:"column:updateTime" json:"-"`                    // 最后更新时间
+	TimeScale    string `gorm:"column:timeScale" json:"-"`                     // 分时图刻度
+	Origin       int    `gorm:"column:origin" json:"origin"`                   // 是否原始数据：1:是，0：否
+	OriginPrice  string `gorm:"column:originPrice" json:"-"`                   // 原始报价 市场价
+	Volume       string `gorm:"column:volume" json:"volume"`                   // 本周期成交量 基础币种, 秒级为本秒增量, 写库时按刻度累加; 数据商原始报价中为交易所原始成交量
+	Session      string `gorm:"-" json:"session,omitempty"`                    // 交易时段标记：open 开盘，close 收盘
+	ExchangeTime int64  `gorm:"-" json:"exchangeTime,omitempty"`               // 交易所行情时间 毫秒, 交易所未提供时为0
+	ReceiveTime  int64  `gorm:"-" json:"receiveTime,omitempty"`                // 本地接收时间 毫秒
+	Bid          string `gorm:"-" json:"bid,omitempty"`                        // 买一价, 聚合后为各数据商最高买价
+	Ask          string `gorm:"-" json:"ask,omitempty"`                        // 卖一价, 聚合后为各数据商最低卖价
+	BidSize      string `gorm:"-" json:"bidSize,omitempty"`                    // 买一量
+	AskSize      string `gorm:"-" json:"askSize,omitempty"`                    // 卖一量
+	Spread       string `gorm:"-" json:"spread,omitempty"`                     // 买卖价差, 聚合后为各数据商价差的平均值
+	QuoteVolume  string `gorm:"-" json:"quoteVolume,omitempty"`                // 以报价币种计量的成交量, 与Volume对应
+	Trades       int    `gorm:"-" json:"trades,omitempty"`                     // 逐笔成交模式下本秒成交笔数
+	Quality      string `gorm:"-" json:"quality,omitempty"`                    // 行情质量：ok 满足quorum，degraded 数据商不足，stale 无可用报价
+
+	Volume24h      string `gorm:"-" json:"volume24h,omitempty"`      // 24小时成交量 基础币种, 聚合后为各数据商之和
+	QuoteVolume24h string `gorm:"-" json:"quoteVolume24h,omitempty"` // 24小时成交量 报价币种, 聚合后为各数据商之和
+	VolumeUnit     string `gorm:"-" json:"volumeUnit,omitempty"`     // 数据商原始报价中Volume的计量单位
+
+	Constituents []Constituent `gorm:"-" json:"constituents,omitempty"` // 聚合k线的各数据商报价及是否参与聚合
 }
 
 func (k *Kline) TableName() string {
@@ -26,18 +43,35 @@ func (k *Kline) TableName() string {
 
 func (k *Kline) Copy() Kline {
 	return Kline{
-		Id:          k.Id,
-		CoinType:    k.CoinType,
-		High:        k.High,
-		Low:         k.Low,
-		Open:        k.Open,
-		Close:       k.Close,
-		CreateTime:  k.CreateTime,
-		UpdateTime:  k.UpdateTime,
-		TimeScale:   k.TimeScale,
-		Origin:      k.Origin,
-		OriginPrice: k.OriginPrice,
-		Volume:      k.Volume,
+		Id:           k.Id,
+		CoinType:     k.CoinType,
+		High:         k.High,
+		Low:          k.Low,
+		Open:         k.Open,
+		Close:        k.Close,
+		CreateTime:   k.CreateTime,
+		UpdateTime:   k.UpdateTime,
+		TimeScale:    k.TimeScale,
+		Origin:       k.Origin,
+		OriginPrice:  k.OriginPrice,
+		Volume:       k.Volume,
+		Session:      k.Session,
+		ExchangeTime: k.ExchangeTime,
+		ReceiveTime:  k.ReceiveTime,
+		Bid:          k.Bid,
+		Ask:          k.Ask,
+		BidSize:      k.BidSize,
+		AskSize:      k.AskSize,
+		Spread:       k.Spread,
+		QuoteVolume:  k.QuoteVolume,
+		Trades:       k.Trades,
+		Quality:      k.Quality,
+
+		Volume24h:      k.Volume24h,
+		QuoteVolume24h: k.QuoteVolume24h,
+		VolumeUnit:     k.VolumeUnit,
+
+		Constituents: append([]Constituent(nil), k.Constituents...),
 	}
 }
 
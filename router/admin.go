@@ -0,0 +1,51 @@
+package router
+
+import (
+	"bitcoin-kline/hub"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 运行时管理数据商, 变更立即生效, 重启后以配置文件为准
+
+// 运行中的数据商及暂停状态
+func AdminProviders(h *hub.Hub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"code": 0,
+			"data": h.ProviderWorker().Providers(),
+		})
+	}
+}
+
+// 启动、停止、暂停、恢复数据商, 路径参数name为数据商名称
+func AdminProvider(h *hub.Hub, action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.Param("name")
+		w := h.ProviderWorker()
+
+		var err error
+		switch action {
+		case "start":
+			err = w.StartProvider(name)
+		case "stop":
+			err = w.StopProvider(name)
+		case "pause":
+			err = w.PauseProvider(name, true)
+		case "resume":
+			err = w.PauseProvider(name, false)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code": 1,
+				"msg":  err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"code": 0,
+			"data": w.Providers(),
+		})
+	}
+}
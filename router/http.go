@@ -0,0 +1,16 @@
+package router
+
+import (
+	"bitcoin-kline/common"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 数据商http请求统计, 按域名汇总耗时与状态码
+func HttpStats(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": common.HttpStats(),
+	})
+}
@@ -0,0 +1,37 @@
+package router
+
+import (
+	"bitcoin-kline/hub"
+	"bitcoin-kline/model"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 某一秒聚合k线的构成, 参数coinType为币种, time为k线时间(秒)
+func KlineConstituents(c *gin.Context) {
+	coinType := c.Query("coinType")
+	createTime, err := strconv.ParseInt(c.Query("time"), 10, 64)
+	if coinType == "" || err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 1,
+			"msg":  "coinType and time required",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": model.GetConstituents(coinType, createTime),
+	})
+}
+
+// 各币种的行情质量状态
+func KlineQuality(h *hub.Hub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"code": 0,
+			"data": h.ProviderWorker().Quality(),
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package router
+
+import (
+	"bitcoin-kline/hub"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 数据商健康状态
+func ProviderStatus(h *hub.Hub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"code": 0,
+			"data": h.ProviderWorker().ProviderStatus(),
+		})
+	}
+}
@@ -1,12 +1,14 @@
 package router
 
 import (
+	"bitcoin-kline/config"
+	"bitcoin-kline/hub"
 	"bitcoin-kline/middleware"
 
 	"github.com/gin-gonic/gin"
 )
 
-func NewEngine() *gin.Engine {
+func NewEngine(h *hub.Hub) *gin.Engine {
 	gin.SetMode(gin.ReleaseMode)
 	engine := gin.New()
 
@@ -17,6 +19,18 @@ func NewEngine() *gin.Engine {
 
 	// router here
 	engine.Any("/", HealthCheck)
+	engine.GET("/provider/status", ProviderStatus(h))
+	engine.GET("/provider/http", HttpStats)
+	engine.GET("/provider/sina/rollovers", SinaRollovers)
+	engine.GET("/kline/constituents", KlineConstituents)
+	engine.GET("/kline/quality", KlineQuality(h))
+
+	// 数据商管理, 需配置[admin]的token并在请求头X-Admin-Token中携带, 未配置时关闭
+	admin := engine.Group("/admin", middleware.AdminAuth(config.GetConfig("admin", "token")))
+	admin.GET("/providers", AdminProviders(h))
+	for _, action := range []string{"start", "stop", "pause", "resume"} {
+		admin.POST("/provider/:name/"+action, AdminProvider(h, action))
+	}
 	return engine
 }
 
@@ -0,0 +1,16 @@
+package router
+
+import (
+	"bitcoin-kline/hub/provider/sina"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 新浪期货连续合约换月记录
+func SinaRollovers(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": sina.Rollovers(),
+	})
+}